@@ -0,0 +1,110 @@
+package gqltesting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// PersistedQueryNotFound is the error message, and the PERSISTED_QUERY_NOT_FOUND the error code,
+// with which a server implementing automatic persisted queries rejects an unknown query hash.
+const PersistedQueryNotFound = "PersistedQueryNotFound"
+
+// A PersistedQueryTest is a test case for RunPersistedQueryTest.
+type PersistedQueryTest struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+
+	// Hash is the hash the query is persisted under. It defaults to the hex-encoded SHA-256 hash
+	// of Query, as automatic persisted queries use.
+	Hash string
+
+	// ExpectedResult is the data expected once the query is registered, compared like
+	// Test.ExpectedResult.
+	ExpectedResult string
+}
+
+// RunPersistedQueryTest exercises the automatic persisted query (APQ) flow of h, typically the
+// handler of a gateway wrapping relay.Handler, by POSTing three requests carrying the hash in the
+// "persistedQuery" extension, as clients do:
+//
+//  1. the hash alone, which must fail with PersistedQueryNotFound as the query is not registered,
+//  2. the hash along with the query, which must register it and return ExpectedResult,
+//  3. the hash alone again, which must now return ExpectedResult.
+//
+// The registry of h must therefore not know the query beforehand. Options configuring the context
+// and the comparison of results apply as for RunTest.
+func RunPersistedQueryTest(t *testing.T, h http.Handler, test *PersistedQueryTest, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	if cfg.parallel {
+		t.Parallel()
+	}
+
+	hash := test.Hash
+	if hash == "" {
+		sum := sha256.Sum256([]byte(test.Query))
+		hash = hex.EncodeToString(sum[:])
+	}
+	body := map[string]interface{}{
+		"operationName": test.OperationName,
+		"variables":     test.Variables,
+		"extensions": map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+		},
+	}
+
+	parent := testContext(&Test{}, cfg)
+	ctx, cancel := timeoutContext(parent, testTimeout(parent, cfg))
+	defer cancel()
+	c := &comparison{useNumber: cfg.useNumber, floatTolerance: cfg.floatTolerance, noColor: cfg.noColor, placeholder: cfg.placeholder}
+
+	result, err := postJSON(ctx, h, body)
+	if err != nil {
+		t.Fatalf("hash only: %s", err)
+	}
+	if !persistedQueryNotFound(result.Errors) {
+		t.Fatalf("hash only: got errors %s, want %s before the query is registered", errorMessages(result.Errors), PersistedQueryNotFound)
+	}
+
+	registering := map[string]interface{}{"query": test.Query}
+	for k, v := range body {
+		registering[k] = v
+	}
+	for _, step := range []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"hash and query", registering},
+		{"hash only, registered", body},
+	} {
+		result, err := postJSON(ctx, h, step.body)
+		if err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+		if len(result.Errors) > 0 {
+			t.Fatalf("%s: got errors %s, want none", step.name, errorMessages(result.Errors))
+		}
+		diff, ok, err := c.compare([]byte(test.ExpectedResult), result.Data)
+		if err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+		if !ok {
+			t.Fatalf("%s: diff:\n%s", step.name, diff)
+		}
+	}
+}
+
+// persistedQueryNotFound reports whether errs hold the error rejecting an unknown query hash.
+func persistedQueryNotFound(errs []*errors.QueryError) bool {
+	for _, err := range errs {
+		if err.Message == PersistedQueryNotFound || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
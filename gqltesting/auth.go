@@ -0,0 +1,76 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// RunAuthorizationTest asserts which fields of the result of test the principal, configured
+// through the options, may see. Every field of denied must fail with an error whose message
+// contains message and be null, either itself or, for a non-null field, through the ancestor the
+// null propagated to, while every field of allowed must resolve to a value. Errors at any other
+// path fail the test, so that a field denied by mistake is reported. The paths are dot-paths
+// relative to the data, as for AssertFieldPresent, such as "user.salary" or "users[].email",
+// where empty brackets match every element. A permission matrix is encoded by running the same
+// test once per principal:
+//
+//	gqltesting.RunAuthorizationTest(t, test, "forbidden",
+//		[]string{"user.salary"}, []string{"user.name", "user.email"},
+//		gqltesting.WithContextValue(roleKey{}, "staff"))
+//
+// The helper only observes the errors and data of the response, so it applies however the schema
+// enforces authorization, such as with resolvers checking a role declared by an @hasRole
+// directive. The expectations of test, such as ExpectedResult, are ignored.
+func RunAuthorizationTest(t *testing.T, test *Test, message string, denied, allowed []string, opts ...Option) {
+	t.Helper()
+	result := execTest(t, test, newRunConfig(opts), testVariables(t, test))
+
+	var v interface{}
+	if len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, &v); err != nil {
+			t.Fatalf("got: %s", invalidJSON(err, result.Data))
+		}
+	}
+
+	matched := make([]bool, len(denied))
+	for _, err := range result.Errors {
+		found := false
+		for i, p := range denied {
+			if parsePath(p).matchesErrorPath(err.Path) {
+				matched[i], found = true, true
+			}
+		}
+		if !found {
+			t.Errorf("unexpected error %q at path %v", err.Message, err.Path)
+			continue
+		}
+
+		if !strings.Contains(err.Message, message) {
+			t.Errorf("error at path %v: got %q, want an authorization error containing %q", err.Path, err.Message, message)
+		}
+		if _, null, walkErr := nullAlongPath(v, err.Path); walkErr != nil {
+			t.Errorf("error %q: %s", err.Message, walkErr)
+		} else if !null {
+			t.Errorf("data at denied path %v is not null; the resolver returned a value despite the error", err.Path)
+		}
+	}
+	for i, p := range denied {
+		if !matched[i] {
+			t.Errorf("%s: no error, want the field to be denied with %q", p, message)
+		}
+	}
+
+	for _, p := range allowed {
+		values := parsePath(p).lookup(v)
+		if len(values) == 0 {
+			t.Errorf("%s: no such field in result, want the field to be allowed", p)
+		}
+		for _, value := range values {
+			if value == nil {
+				t.Errorf("%s: got null, want the field to be allowed", p)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package gqltesting
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// base64Encodings are the encodings tried, in order, to decode the fields given to
+// WithBase64Fields.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.URLEncoding,
+	base64.RawStdEncoding,
+	base64.RawURLEncoding,
+}
+
+// base64Diff describes the base64 fields at paths whose values differ between the decoded
+// expected and actual results with a hexdump of both decoded values, or returns "" if there are
+// none. Values that are not valid base64 are left to the regular diff.
+func base64Diff(paths []string, want, got interface{}) string {
+	var b strings.Builder
+	for _, p := range paths {
+		wantValues, gotValues := parsePath(p).lookup(want), parsePath(p).lookup(got)
+		for i := 0; i < len(wantValues) && i < len(gotValues); i++ {
+			w, wok := wantValues[i].(string)
+			g, gok := gotValues[i].(string)
+			if !wok || !gok || w == g {
+				continue
+			}
+			wb, wok := decodeBase64(w)
+			gb, gok := decodeBase64(g)
+			if !wok || !gok {
+				continue
+			}
+			name := p
+			if len(wantValues) > 1 {
+				name = fmt.Sprintf("%s (value %d)", p, i+1)
+			}
+			fmt.Fprintf(&b, "%s differs:\nwant (%d bytes):\n%sgot (%d bytes):\n%s", name, len(wb), hex.Dump(wb), len(gb), hex.Dump(gb))
+		}
+	}
+	return b.String()
+}
+
+func decodeBase64(s string) ([]byte, bool) {
+	for _, enc := range base64Encodings {
+		if data, err := enc.DecodeString(s); err == nil {
+			return data, true
+		}
+	}
+	return nil, false
+}
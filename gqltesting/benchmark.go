@@ -0,0 +1,62 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// RunBenchmark executes test.Query b.N times. The query is executed once before the timer is
+// reset, and if test.ExpectedResult is set, that first result is compared against it so that a
+// broken query is not benchmarked. The size of the serialized response is reported as bytes per
+// operation. With WithParallel, the query is executed concurrently using b.RunParallel. With
+// WithPreparedQuery, the query is parsed only once, before the timer is reset.
+func RunBenchmark(b *testing.B, test *Test, opts ...Option) {
+	cfg := newRunConfig(opts)
+	variables := testVariables(b, test)
+	ctx, cancel := timeoutContext(testContext(test, cfg), cfg.timeout)
+	defer cancel()
+
+	exec := func() *graphql.Response {
+		return test.Schema.Exec(ctx, test.Query, test.OperationName, variables)
+	}
+	if cfg.prepared {
+		q, err := test.Schema.Prepare(test.Query)
+		if err != nil {
+			b.Fatalf("preparing query: %s", err)
+		}
+		exec = func() *graphql.Response {
+			return q.Exec(ctx, test.OperationName, variables)
+		}
+	}
+
+	result := exec()
+	if test.ExpectedResult != "" {
+		diff, ok, err := testComparison(test, cfg).compare([]byte(test.ExpectedResult), result.Data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !ok {
+			b.Fatalf("unexpected result:\n%s", diff)
+		}
+	}
+	resp, err := json.Marshal(result)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(resp)))
+	b.ResetTimer()
+
+	if cfg.parallel {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				exec()
+			}
+		})
+		return
+	}
+	for i := 0; i < b.N; i++ {
+		exec()
+	}
+}
@@ -0,0 +1,143 @@
+package gqltesting
+
+import (
+	"context"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// TestBuilder constructs a Test fluently, as an alternative to a struct literal for hand-written
+// cases:
+//
+//	test := gqltesting.NewTest(schema).
+//		Query(`query($id: ID!) { user(id: $id) { name } }`).
+//		Var("id", 1).
+//		Expect(`{"user": {"name": "Alice"}}`).
+//		Build()
+//
+// Fields without a method of their own can be set on the Test returned by Build.
+type TestBuilder struct {
+	test Test
+}
+
+// NewTest returns a builder for a test case executed against schema.
+func NewTest(schema *graphql.Schema) *TestBuilder {
+	return &TestBuilder{test: Test{Schema: schema}}
+}
+
+// Name sets the name of the subtest.
+func (b *TestBuilder) Name(name string) *TestBuilder {
+	b.test.Name = name
+	return b
+}
+
+// Context sets the context the test is executed with.
+func (b *TestBuilder) Context(ctx context.Context) *TestBuilder {
+	b.test.Context = ctx
+	return b
+}
+
+// ContextValue adds a value to the context the test is executed with, see Test.ContextValues.
+func (b *TestBuilder) ContextValue(key, value interface{}) *TestBuilder {
+	if b.test.ContextValues == nil {
+		b.test.ContextValues = make(map[interface{}]interface{})
+	}
+	b.test.ContextValues[key] = value
+	return b
+}
+
+// Query sets the query to execute.
+func (b *TestBuilder) Query(query string) *TestBuilder {
+	b.test.Query = query
+	return b
+}
+
+// OperationName selects the operation of the query to execute.
+func (b *TestBuilder) OperationName(name string) *TestBuilder {
+	b.test.OperationName = name
+	return b
+}
+
+// Var sets the variable name to value.
+func (b *TestBuilder) Var(name string, value interface{}) *TestBuilder {
+	if b.test.Variables == nil {
+		b.test.Variables = make(map[string]interface{})
+	}
+	b.test.Variables[name] = value
+	return b
+}
+
+// Expect sets the expected result, as JSON.
+func (b *TestBuilder) Expect(result string) *TestBuilder {
+	b.test.ExpectedResult = result
+	return b
+}
+
+// ExpectError adds err to the expected errors.
+func (b *TestBuilder) ExpectError(err *errors.QueryError) *TestBuilder {
+	b.test.ExpectedErrors = append(b.test.ExpectedErrors, err)
+	return b
+}
+
+// ExpectNoErrors asserts that the operation returns no errors, see Test.ExpectNoErrors.
+func (b *TestBuilder) ExpectNoErrors() *TestBuilder {
+	b.test.ExpectNoErrors = true
+	return b
+}
+
+// ExpectErrorCount asserts that the operation returns exactly n errors, see
+// Test.ExpectedErrorCount.
+func (b *TestBuilder) ExpectErrorCount(n int) *TestBuilder {
+	b.test.ExpectedErrorCount = &n
+	return b
+}
+
+// ExpectNullData asserts that the response data is exactly JSON null, see Test.ExpectNullData.
+func (b *TestBuilder) ExpectNullData() *TestBuilder {
+	b.test.ExpectNullData = true
+	return b
+}
+
+// IgnoreFields adds paths of fields that are left out of the comparison, see Test.IgnoreFields.
+func (b *TestBuilder) IgnoreFields(paths ...string) *TestBuilder {
+	b.test.IgnoreFields = append(b.test.IgnoreFields, paths...)
+	return b
+}
+
+// MatchField sets the predicate the field at path must satisfy, see Test.FieldMatchers.
+func (b *TestBuilder) MatchField(path string, m FieldMatcher) *TestBuilder {
+	if b.test.FieldMatchers == nil {
+		b.test.FieldMatchers = make(map[string]FieldMatcher)
+	}
+	b.test.FieldMatchers[path] = m
+	return b
+}
+
+// Build returns the test case. The builder may be reused to derive further cases: the returned
+// Test does not share its maps and slices with the builder, so building a variant does not
+// change the tests built before.
+func (b *TestBuilder) Build() *Test {
+	test := b.test
+	if b.test.Variables != nil {
+		test.Variables = make(map[string]interface{}, len(b.test.Variables))
+		for k, v := range b.test.Variables {
+			test.Variables[k] = v
+		}
+	}
+	if b.test.ContextValues != nil {
+		test.ContextValues = make(map[interface{}]interface{}, len(b.test.ContextValues))
+		for k, v := range b.test.ContextValues {
+			test.ContextValues[k] = v
+		}
+	}
+	if b.test.FieldMatchers != nil {
+		test.FieldMatchers = make(map[string]FieldMatcher, len(b.test.FieldMatchers))
+		for k, v := range b.test.FieldMatchers {
+			test.FieldMatchers[k] = v
+		}
+	}
+	test.ExpectedErrors = append([]*errors.QueryError(nil), b.test.ExpectedErrors...)
+	test.IgnoreFields = append([]string(nil), b.test.IgnoreFields...)
+	return &test
+}
@@ -0,0 +1,69 @@
+package gqltesting
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// A CallLog records, in order, the calls made to backends while a test executes, so that
+// Test.ExpectedCallLog can assert how they were batched or cached. RunTest installs a fresh
+// CallLog in the context of tests setting ExpectedCallLog, which resolvers and the loaders they
+// use retrieve with CallLogFromContext:
+//
+//	func (l *userLoader) loadBatch(ctx context.Context, ids []string) []*User {
+//		gqltesting.CallLogFromContext(ctx).Add("users " + strings.Join(ids, ","))
+//		...
+//	}
+//
+// A CallLog is safe for concurrent use. Its methods are no-ops on a nil CallLog, which is what
+// CallLogFromContext returns outside of tests, so production code can log calls unconditionally.
+type CallLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+type callLogKey struct{}
+
+// CallLogFromContext returns the CallLog installed by RunTest in ctx, or nil if there is none.
+func CallLogFromContext(ctx context.Context) *CallLog {
+	log, _ := ctx.Value(callLogKey{}).(*CallLog)
+	return log
+}
+
+func withCallLog(ctx context.Context) (context.Context, *CallLog) {
+	log := &CallLog{}
+	return context.WithValue(ctx, callLogKey{}, log), log
+}
+
+// Add appends entry to the log.
+func (l *CallLog) Add(entry string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// Entries returns a copy of the entries of the log, in the order they were added.
+func (l *CallLog) Entries() []string {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.entries...)
+}
+
+// check fails the test unless the entries of the log are exactly want.
+func (l *CallLog) check(t testing.TB, want []string) {
+	got := l.Entries()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got call log %q, want %q", got, want)
+	}
+}
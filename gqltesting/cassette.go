@@ -0,0 +1,332 @@
+package gqltesting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// RecordEnv is the environment variable that, set to a non-empty value, makes WithCassette record
+// the backend calls of resolvers instead of replaying them.
+const RecordEnv = "GQLTESTING_RECORD"
+
+// cassetteMu serializes the updates of cassette files, which concurrent tests may share.
+var cassetteMu sync.Mutex
+
+// A cassetteFile is the content of a file recorded by WithCassette.
+type cassetteFile struct {
+	Recordings []*recording `json:"recordings"`
+}
+
+// A recording holds the backend calls made while executing a query with its variables.
+type recording struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	Calls         []*recordedCall `json:"calls"`
+}
+
+// A recordedCall is the outcome of a backend call, identified by its name and arguments.
+type recordedCall struct {
+	Name   string          `json:"name"`
+	Args   json.RawMessage `json:"args,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// WithCassette records the backend calls resolvers make through Cassette.Do and replays them, so
+// that tests against slow or external services run fast and offline while the schema, validation
+// and resolvers still execute. When the environment variable named by RecordEnv is set, each call
+// is made and its outcome recorded into the JSON file at path, replacing any previous recording of
+// the same query. Otherwise the recorded outcomes are returned without calling the backend, and a
+// test whose query or call was never recorded fails.
+//
+// Recordings are keyed by the operation name, the variables and the query with insignificant
+// whitespace, commas and comments removed, so that reformatting a query does not invalidate them.
+func WithCassette(path string) Option {
+	return func(cfg *runConfig) {
+		cfg.cassette = path
+	}
+}
+
+// A Cassette records and replays the backend calls of resolvers for WithCassette. RunTest installs
+// one in the context of tests using WithCassette, which resolvers retrieve with
+// CassetteFromContext:
+//
+//	func (r *Resolver) Weather(ctx context.Context, args struct{ City string }) (*Forecast, error) {
+//		var f Forecast
+//		err := gqltesting.CassetteFromContext(ctx).Do("weather", args.City, &f, func() error {
+//			return r.client.Get("/weather/"+args.City, &f)
+//		})
+//		return &f, err
+//	}
+//
+// A Cassette is safe for concurrent use. Its methods make the call directly on a nil Cassette,
+// which is what CassetteFromContext returns outside of such tests, so production code can use it
+// unconditionally.
+type Cassette struct {
+	path   string
+	replay bool
+
+	mu      sync.Mutex
+	calls   map[string]*recordedCall
+	missing []string
+}
+
+type cassetteKey struct{}
+
+// CassetteFromContext returns the Cassette installed by WithCassette in ctx, or nil if there is
+// none.
+func CassetteFromContext(ctx context.Context) *Cassette {
+	c, _ := ctx.Value(cassetteKey{}).(*Cassette)
+	return c
+}
+
+// Do makes the backend call named name with args, which fills result. When recording, call is
+// made and its outcome recorded; when replaying, result is decoded from the recording instead, so
+// it must survive a round trip through JSON. An error of call is recorded by its message, and in
+// both modes returned as a new error with that message, so that a test sees the same error
+// whether it records or replays. Calls with the same name and arguments share a single recording.
+func (c *Cassette) Do(name string, args, result interface{}, call func() error) error {
+	if c == nil {
+		return call()
+	}
+	key := &recordedCall{Name: name}
+	if args != nil {
+		data, err := encodeJSON(args)
+		if err != nil {
+			return fmt.Errorf("cassette: arguments of %s: %s", name, err)
+		}
+		key.Args = data
+	}
+	id := key.Name + " " + string(key.Args)
+
+	if c.replay {
+		c.mu.Lock()
+		r := c.calls[id]
+		if r == nil {
+			c.missing = append(c.missing, id)
+		}
+		c.mu.Unlock()
+		if r == nil {
+			return fmt.Errorf("cassette %s has no recording of call %s", c.path, id)
+		}
+		if r.Error != "" {
+			return errors.New(r.Error)
+		}
+		if len(r.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(r.Result, result)
+	}
+
+	if err := call(); err != nil {
+		key.Error = err.Error()
+	} else if result != nil {
+		data, err := encodeJSON(result)
+		if err != nil {
+			return fmt.Errorf("cassette: result of %s: %s", name, err)
+		}
+		key.Result = data
+	}
+	c.mu.Lock()
+	if _, ok := c.calls[id]; !ok {
+		c.calls[id] = key
+	}
+	c.mu.Unlock()
+	if key.Error != "" {
+		return errors.New(key.Error)
+	}
+	return nil
+}
+
+// recorded returns the calls recorded so far, sorted by name and arguments.
+func (c *Cassette) recorded() []*recordedCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.calls))
+	for id := range c.calls {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	calls := make([]*recordedCall, len(ids))
+	for i, id := range ids {
+		calls[i] = c.calls[id]
+	}
+	return calls
+}
+
+func cassetteExec(exec execFunc, path string) execFunc {
+	return func(ctx context.Context, test *Test, variables map[string]interface{}) (*graphql.Response, error) {
+		key, err := newRecording(test, variables)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Cassette{path: path, replay: os.Getenv(RecordEnv) == "", calls: make(map[string]*recordedCall)}
+		if c.replay {
+			f, err := readCassette(path)
+			if err != nil {
+				return nil, err
+			}
+			r := f.find(key)
+			if r == nil {
+				return nil, fmt.Errorf("cassette %s has no recording of query %q; run the test with %s=1 to record it", path, key.Query, RecordEnv)
+			}
+			for _, call := range r.Calls {
+				c.calls[call.Name+" "+string(call.Args)] = call
+			}
+		}
+
+		result, err := exec(context.WithValue(ctx, cassetteKey{}, c), test, variables)
+		if err != nil {
+			return nil, err
+		}
+		if c.replay {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if len(c.missing) > 0 {
+				return nil, fmt.Errorf("cassette %s has no recording of calls %s of query %q; run the test with %s=1 to record them", path, strings.Join(c.missing, ", "), key.Query, RecordEnv)
+			}
+			return result, nil
+		}
+		key.Calls = c.recorded()
+		return result, recordCassette(path, key)
+	}
+}
+
+// newRecording returns a recording of test, without calls, keyed canonically.
+func newRecording(test *Test, variables map[string]interface{}) (*recording, error) {
+	r := &recording{Query: canonicalQuery(test.Query), OperationName: test.OperationName}
+	if len(variables) > 0 {
+		data, err := encodeJSON(variables)
+		if err != nil {
+			return nil, fmt.Errorf("variables: %s", err)
+		}
+		r.Variables = data
+	}
+	return r, nil
+}
+
+func (f *cassetteFile) find(key *recording) *recording {
+	for _, r := range f.Recordings {
+		if r.Query == key.Query && r.OperationName == key.OperationName && string(r.Variables) == string(key.Variables) {
+			return r
+		}
+	}
+	return nil
+}
+
+func readCassette(path string) (*cassetteFile, error) {
+	f := &cassetteFile{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %s", err)
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("cassette %s: %s", path, err)
+	}
+	return f, nil
+}
+
+// recordCassette adds r to the cassette at path, replacing any recording with the same key.
+func recordCassette(path string, r *recording) error {
+	cassetteMu.Lock()
+	defer cassetteMu.Unlock()
+
+	f, err := readCassette(path)
+	if err != nil {
+		return err
+	}
+	if old := f.find(r); old != nil {
+		*old = *r
+	} else {
+		f.Recordings = append(f.Recordings, r)
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := writeGolden(path, append(data, '\n'), nil); err != nil {
+		return fmt.Errorf("recording cassette: %s", err)
+	}
+	return nil
+}
+
+// canonicalQuery returns query with its tokens separated by single spaces, dropping the
+// whitespace, commas and comments the GraphQL grammar ignores. String literals are kept verbatim.
+func canonicalQuery(query string) string {
+	var tokens []string
+	r := []rune(query)
+	at := func(i int, prefix string) bool {
+		return strings.HasPrefix(string(r[i:minInt(i+len(prefix), len(r))]), prefix)
+	}
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c) || c == ',' || c == '\uFEFF':
+			i++
+		case c == '#':
+			for i < len(r) && r[i] != '\n' && r[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			start := i
+			if at(i, `"""`) {
+				i += 3
+				for i < len(r) && !at(i, `"""`) {
+					if r[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				i += 3
+			} else {
+				i++
+				for i < len(r) && r[i] != '"' && r[i] != '\n' {
+					if r[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				i++
+			}
+			if i > len(r) {
+				i = len(r)
+			}
+			tokens = append(tokens, string(r[start:i]))
+		case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '.' && !at(i, "..."):
+			start := i
+			for i < len(r) && (r[i] == '_' || r[i] == '-' || r[i] == '+' || r[i] == '.' || unicode.IsLetter(r[i]) || unicode.IsDigit(r[i])) {
+				i++
+			}
+			tokens = append(tokens, string(r[start:i]))
+		case at(i, "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,117 @@
+package gqltesting
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+func TestCanonicalQuery(t *testing.T) {
+	want := canonicalQuery(`query Q($id: ID!) { user(id: $id, note: "a,  b # c") { ...F } }`)
+	for _, q := range []string{
+		`query Q($id:ID!){user(id:$id note:"a,  b # c"){...F}}`,
+		"# comment\nquery Q(\n\t$id: ID!\n) {\n\tuser(id: $id, note: \"a,  b # c\") {\n\t\t... F\n\t}\n}\n",
+	} {
+		if got := canonicalQuery(q); got != want {
+			t.Errorf("canonicalQuery(%q) = %q, want %q", q, got, want)
+		}
+	}
+	if got := canonicalQuery(`{ user(note: "a b") }`); got == canonicalQuery(`{ user(note: "a  b") }`) {
+		t.Errorf("string literals differing in whitespace share the key %q", got)
+	}
+}
+
+// weatherResolver fetches temperatures through the cassette of the context, from a backend that
+// counts its calls. offset simulates a regression of the resolver.
+type weatherResolver struct {
+	calls  int32
+	offset int32
+}
+
+func (r *weatherResolver) Temperature(ctx context.Context, args struct{ City string }) (*int32, error) {
+	var celsius int32
+	err := CassetteFromContext(ctx).Do("temperature", args.City, &celsius, func() error {
+		atomic.AddInt32(&r.calls, 1)
+		if args.City == "Atlantis" {
+			return fmt.Errorf("unknown city %s", args.City)
+		}
+		celsius = int32(len(args.City))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	celsius += r.offset
+	return &celsius, nil
+}
+
+func TestWithCassette(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqltesting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	run := func(r *weatherResolver, query string) *fakeTB {
+		schema := graphql.MustParseSchema(`
+			type Query {
+				temperature(city: String!): Int
+			}
+		`, r)
+		return runFake(func(tb testing.TB) {
+			runTest(tb, &Test{
+				Schema:         schema,
+				Query:          query,
+				ExpectedResult: `{"paris": 5, "atlantis": null}`,
+				ExpectedErrors: []*errors.QueryError{{
+					Message:       "unknown city Atlantis",
+					Path:          []interface{}{"atlantis"},
+					ResolverError: fmt.Errorf("unknown city Atlantis"),
+				}},
+			}, newRunConfig([]Option{WithCassette(path)}))
+		})
+	}
+	const query = `{ paris: temperature(city: "Paris") atlantis: temperature(city: "Atlantis") }`
+
+	r := &weatherResolver{}
+	if tb := run(r, query); !tb.Failed() {
+		t.Error("replaying an empty cassette did not fail")
+	}
+
+	os.Setenv(RecordEnv, "1")
+	tb := run(r, query)
+	os.Unsetenv(RecordEnv)
+	if tb.Failed() {
+		t.Fatalf("recording failed:\n%s", tb.String())
+	}
+
+	if tb := run(r, "{\n\tparis: temperature(city: \"Paris\")\n\tatlantis: temperature(city: \"Atlantis\")\n}"); tb.Failed() {
+		t.Errorf("replaying failed:\n%s", tb.String())
+	}
+	if r.calls != 2 {
+		t.Errorf("backend called %d times, want twice while recording", r.calls)
+	}
+
+	if tb := run(&weatherResolver{offset: 1}, query); !tb.Failed() {
+		t.Error("replaying did not catch a regression of the resolver")
+	}
+	if tb := run(r, `{ paris: temperature(city: "Paris") atlantis: temperature(city: "Rome") }`); !tb.Failed() {
+		t.Error("replaying an unrecorded query did not fail")
+	}
+}
+
+func TestCassette_nil(t *testing.T) {
+	var c *Cassette
+	var got int
+	if err := c.Do("answer", nil, &got, func() error { got = 42; return nil }); err != nil || got != 42 {
+		t.Errorf("got %d, %v, want the call to be made", got, err)
+	}
+}
@@ -0,0 +1,285 @@
+package gqltesting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Compare reports whether the expected and actual JSON documents are equal once normalized, and
+// if not, returns a diff produced by DiffFunc. It returns an error if either document is not valid
+// JSON. Unlike RunTest, Compare does not interact with testing.T, so it can be embedded in custom
+// assertions, fuzz targets and other harnesses.
+func Compare(expected, got []byte) (diff string, ok bool, err error) {
+	return (&comparison{}).compare(expected, got)
+}
+
+// A comparison describes how an expected and an actual result are compared.
+type comparison struct {
+	normalizers []normalizer
+
+	// useNumber decodes numbers as json.Number, preserving their literal text, instead of float64.
+	useNumber bool
+
+	// floatTolerance, if positive, is the largest absolute or relative difference between two
+	// numbers that are still considered equal.
+	floatTolerance float64
+
+	// noColor disables the colorization of diffs, see colorEnabled.
+	noColor bool
+
+	// placeholder, if not empty, is a string that matches any value when it is a leaf of the
+	// expected document.
+	placeholder string
+
+	// base64Paths are the paths of base64 fields whose decoded values are dumped in diffs.
+	base64Paths []string
+}
+
+func (c *comparison) compare(expected, actual []byte) (string, bool, error) {
+	// Verify JSON to avoid red herring errors.
+	gotValue, err := c.decode(actual)
+	if err != nil {
+		return "", false, fmt.Errorf("got: %s", invalidJSON(err, actual))
+	}
+	wantValue, err := c.decode(expected)
+	if err != nil {
+		return "", false, fmt.Errorf("want: invalid JSON: %s", err)
+	}
+	if c.placeholder != "" {
+		wantValue = fillPlaceholders(wantValue, gotValue, c.placeholder)
+	}
+	got, err := encodeJSON(gotValue)
+	if err != nil {
+		return "", false, err
+	}
+	want, err := encodeJSON(wantValue)
+	if err != nil {
+		return "", false, err
+	}
+
+	if bytes.Equal(got, want) {
+		return "", true, nil
+	}
+	if c.floatTolerance > 0 && equalWithin(wantValue, gotValue, c.floatTolerance) {
+		return "", true, nil
+	}
+	diff := diffJSON(want, got)
+	if colorEnabled && !c.noColor {
+		diff = colorizeDiff(diff)
+	}
+	if dump := base64Diff(c.base64Paths, wantValue, gotValue); dump != "" {
+		diff += "\n" + dump
+	}
+	return diff, false, nil
+}
+
+// decode decodes data and applies the comparison's normalizers to the resulting tree.
+func (c *comparison) decode(data []byte) (interface{}, error) {
+	v, err := decodeJSON(data, c.useNumber)
+	if err != nil {
+		return nil, err
+	}
+	for _, normalize := range c.normalizers {
+		v = normalize(v)
+	}
+	return v, nil
+}
+
+// fillPlaceholders replaces every placeholder leaf of want with the value at the same position in
+// got, if there is one, so that it compares equal whatever its value.
+func fillPlaceholders(want, got interface{}, placeholder string) interface{} {
+	switch w := want.(type) {
+	case string:
+		if w == placeholder {
+			return got
+		}
+	case map[string]interface{}:
+		if g, ok := got.(map[string]interface{}); ok {
+			for k, v := range w {
+				if gv, ok := g[k]; ok {
+					w[k] = fillPlaceholders(v, gv, placeholder)
+				}
+			}
+		}
+	case []interface{}:
+		if g, ok := got.([]interface{}); ok {
+			for i := range w {
+				if i < len(g) {
+					w[i] = fillPlaceholders(w[i], g[i], placeholder)
+				}
+			}
+		}
+	}
+	return want
+}
+
+// equalWithin reports whether two decoded JSON values are equal, treating numbers as equal when
+// their absolute or relative difference is at most eps.
+func equalWithin(want, got interface{}, eps float64) bool {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		got, ok := got.(map[string]interface{})
+		if !ok || len(got) != len(want) {
+			return false
+		}
+		for k, w := range want {
+			g, ok := got[k]
+			if !ok || !equalWithin(w, g, eps) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		got, ok := got.([]interface{})
+		if !ok || len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if !equalWithin(want[i], got[i], eps) {
+				return false
+			}
+		}
+		return true
+	case float64, json.Number:
+		w, wok := jsonFloat(want)
+		g, gok := jsonFloat(got)
+		if !wok || !gok {
+			return false
+		}
+		diff := math.Abs(w - g)
+		return diff <= eps || diff <= eps*math.Max(math.Abs(w), math.Abs(g))
+	default:
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+// diffJSON describes the difference between the formatted expected and actual JSON using
+// DiffFunc, falling back to showing both documents if no diff can be produced. With KeepDiffFiles,
+// it also names the directory the documents are kept in.
+func diffJSON(want, got []byte) string {
+	diff, err := DiffFunc(indentJSON(want), indentJSON(got))
+	if err != nil || diff == "" {
+		diff = fmt.Sprintf("got:  %s\nwant: %s", got, want)
+	}
+	if KeepDiffFiles {
+		if dir, err := writeDiffFiles(indentJSON(want), indentJSON(got)); err != nil {
+			diff += fmt.Sprintf("\nkeeping diff files: %s", err)
+		} else {
+			diff = strings.TrimSuffix(diff, "\n") + fmt.Sprintf("\nexpected and actual results kept in %s\n", dir)
+		}
+	}
+	return diff
+}
+
+// indentJSON splits formatted JSON over multiple lines so that line-based diffs are meaningful.
+func indentJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return data
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func jsonFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// FormatJSON re-encodes the JSON document data in the canonical form RunTest compares results in:
+// compact, with the keys of every object sorted at every level and without HTML escaping. Decoding
+// discards the key order chosen by the original encoder, such as a custom MarshalJSON, so
+// semantically equal documents always format identically and can be compared with bytes.Equal.
+// Already canonical input is returned byte for byte.
+func FormatJSON(data []byte) ([]byte, error) {
+	v, err := (&comparison{}).decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodeJSON(v)
+}
+
+// encodeJSON encodes a decoded JSON tree with sorted object keys and without HTML escaping, so that
+// already canonical input is reproduced byte for byte.
+func encodeJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// maxRawJSON is how many bytes of invalid JSON are shown in failure messages.
+const maxRawJSON = 256
+
+// invalidJSON describes err, the error decoding data, along with the offset at which decoding
+// failed and the raw data, truncated to maxRawJSON bytes around that offset. It is meant to
+// diagnose executors or custom MarshalJSON methods producing malformed output.
+func invalidJSON(err error, data []byte) string {
+	offset := int64(-1)
+	switch err := err.(type) {
+	case *json.SyntaxError:
+		offset = err.Offset
+	case *json.UnmarshalTypeError:
+		offset = err.Offset
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid JSON: %s", err)
+	if offset >= 0 {
+		fmt.Fprintf(&b, " at offset %d", offset)
+	}
+	if len(data) <= maxRawJSON {
+		fmt.Fprintf(&b, "\nraw: %q", data)
+		return b.String()
+	}
+
+	start := 0
+	if offset > maxRawJSON/2 {
+		start = int(offset) - maxRawJSON/2
+	}
+	end := start + maxRawJSON
+	if end > len(data) {
+		end, start = len(data), len(data)-maxRawJSON
+	}
+	fmt.Fprintf(&b, "\nraw (bytes %d-%d of %d): %q", start, end, len(data), data[start:end])
+	return b.String()
+}
+
+// decodeJSON decodes data into generic values, using json.Number for numbers if useNumber is set.
+func decodeJSON(data []byte, useNumber bool) (interface{}, error) {
+	var v interface{}
+	if !useNumber {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid data after top-level value")
+	}
+	return v, nil
+}
@@ -0,0 +1,185 @@
+package gqltesting
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatJSON_canonical(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "already canonical",
+			in:   `{"a":[{"x":1,"y":"<b>"}],"b":null}`,
+			want: `{"a":[{"x":1,"y":"<b>"}],"b":null}`,
+		},
+		{
+			name: "nested key order",
+			in:   `{"b": null, "a": [{"y": "<b>", "x": 1}]}`,
+			want: `{"a":[{"x":1,"y":"<b>"}],"b":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatJSON([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComparison_useNumber(t *testing.T) {
+	want := []byte(`{"id": 9007199254740993}`)
+	got := []byte(`{"id": 9007199254740992}`)
+
+	if _, ok, err := (&comparison{}).compare(want, got); err != nil || !ok {
+		t.Errorf("expected float64 comparison to round both values to the same number")
+	}
+	if _, ok, err := (&comparison{useNumber: true}).compare(want, got); err != nil || ok {
+		t.Errorf("expected json.Number comparison to tell the values apart")
+	}
+	if _, _, err := (&comparison{useNumber: true}).compare(want, []byte(`{} {}`)); err == nil {
+		t.Errorf("expected trailing data to be rejected")
+	}
+}
+
+func TestComparison_placeholder(t *testing.T) {
+	c := &comparison{placeholder: "<ANY>", noColor: true}
+	tests := []struct {
+		name string
+		want string
+		got  string
+		ok   bool
+	}{
+		{"scalar", `{"id": "<ANY>", "name": "a"}`, `{"id": 42, "name": "a"}`, true},
+		{"object and null", `{"user": "<ANY>", "viewer": "<ANY>"}`, `{"user": {"id": 1}, "viewer": null}`, true},
+		{"list elements", `{"ids": ["<ANY>", 2]}`, `{"ids": [1, 2]}`, true},
+		{"missing field", `{"id": "<ANY>"}`, `{}`, false},
+		{"other fields still compared", `{"id": "<ANY>", "name": "a"}`, `{"id": 42, "name": "b"}`, false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok, err := c.compare([]byte(tt.want), []byte(tt.got)); err != nil || ok != tt.ok {
+				t.Errorf("got ok %v, err %v, want ok %v", ok, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestInvalidJSON(t *testing.T) {
+	short := []byte(`{"a": 1,}`)
+	_, err := decodeJSON(short, false)
+	if got, want := invalidJSON(err, short), "invalid JSON: invalid character '}' looking for beginning of object key string at offset 9\nraw: \"{\\\"a\\\": 1,}\""; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	long := []byte(`{"a": "` + strings.Repeat("x", 1000) + `" "b": "` + strings.Repeat("y", 1000) + `"}`)
+	_, err = decodeJSON(long, true)
+	got := invalidJSON(err, long)
+	if want := "at offset 1010\nraw (bytes 882-1138 of 2017): "; !strings.Contains(got, want) {
+		t.Errorf("got %q, want it to contain %q", got, want)
+	}
+}
+
+func TestComparison_base64Paths(t *testing.T) {
+	c := &comparison{base64Paths: []string{"files[].content"}, noColor: true}
+	want := []byte(`{"files": [{"content": "AAEC"}, {"content": "AwQF"}]}`)
+	got := []byte(`{"files": [{"content": "AAEC"}, {"content": "AwQG"}]}`)
+
+	diff, ok, err := c.compare(want, got)
+	if err != nil || ok {
+		t.Fatalf("got ok %v, err %v, want a difference", ok, err)
+	}
+	for _, s := range []string{
+		"files[].content (value 2) differs:",
+		"want (3 bytes):\n00000000  03 04 05",
+		"got (3 bytes):\n00000000  03 04 06",
+	} {
+		if !strings.Contains(diff, s) {
+			t.Errorf("diff %q does not contain %q", diff, s)
+		}
+	}
+	if strings.Contains(diff, "00 01 02") {
+		t.Errorf("diff %q dumps the equal value", diff)
+	}
+}
+
+func TestComparison_numericIDs(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+		got   string
+		equal bool
+	}{
+		{
+			name:  "number matches string at path",
+			paths: []string{"data.users[].id"},
+			want:  `{"users": [{"id": "1"}, {"id": "2"}]}`,
+			got:   `{"users": [{"id": 1}, {"id": 2}]}`,
+			equal: true,
+		},
+		{
+			name:  "other numbers are kept",
+			paths: []string{"data.users[].id"},
+			want:  `{"users": [{"id": "1", "age": "30"}]}`,
+			got:   `{"users": [{"id": 1, "age": 30}]}`,
+		},
+		{
+			name:  "every number without paths",
+			want:  `{"users": [{"id": "1", "age": "30"}]}`,
+			got:   `{"users": [{"id": 1, "age": 30}]}`,
+			equal: true,
+		},
+		{
+			name:  "list of IDs",
+			paths: []string{"ids"},
+			want:  `{"ids": ["1", 2]}`,
+			got:   `{"ids": [1, "2"]}`,
+			equal: true,
+		},
+		{
+			name: "leading zeros",
+			want: `{"id": "0123"}`,
+			got:  `{"id": 123}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := &comparison{normalizers: []normalizer{numericIDs(tt.paths)}}
+			_, ok, err := c.compare([]byte(tt.want), []byte(tt.got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.equal {
+				t.Errorf("got equal %v, want %v", ok, tt.equal)
+			}
+		})
+	}
+}
+
+func TestComparison_jsonStrings(t *testing.T) {
+	c := &comparison{normalizers: []normalizer{jsonStrings([]string{"data.settings[].value"})}, placeholder: DefaultPlaceholder}
+	want := []byte(`{"settings": [{"value": "{\"a\": 1, \"b\": {\"c\": \"<ANY>\"}}"}, {"value": "plain"}]}`)
+
+	got := []byte(`{"settings": [{"value": "{\"b\":{\"c\":true},\"a\":1}"}, {"value": "plain"}]}`)
+	if _, ok, err := c.compare(want, got); err != nil || !ok {
+		t.Errorf("expected JSON strings with reordered keys to be equal")
+	}
+	got = []byte(`{"settings": [{"value": "{\"b\":{\"c\":true},\"a\":2}"}, {"value": "plain"}]}`)
+	if _, ok, err := c.compare(want, got); err != nil || ok {
+		t.Errorf("expected JSON strings with different values to differ")
+	}
+}
@@ -0,0 +1,65 @@
+package gqltesting_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		got      string
+		wantOK   bool
+		wantDiff string
+		wantErr  string
+	}{
+		{
+			name:     "equal after normalization",
+			expected: `{"a": 1, "b": [true, null]}`,
+			got:      `{"b":[true,null],"a":1}`,
+			wantOK:   true,
+		},
+		{
+			name:     "different",
+			expected: `{"a": 1}`,
+			got:      `{"a": 2}`,
+			wantDiff: "-  \"a\": 1\n+  \"a\": 2\n",
+		},
+		{
+			name:     "invalid actual",
+			expected: `{}`,
+			got:      `{`,
+			wantErr:  "got: invalid JSON",
+		},
+		{
+			name:     "invalid expected",
+			expected: `nope`,
+			got:      `{}`,
+			wantErr:  "want: invalid JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, ok, err := gqltesting.Compare([]byte(tt.expected), []byte(tt.got))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("got ok %t, want %t", ok, tt.wantOK)
+			}
+			if !strings.Contains(diff, tt.wantDiff) {
+				t.Errorf("got diff:\n%s\nwant it to contain:\n%s", diff, tt.wantDiff)
+			}
+		})
+	}
+}
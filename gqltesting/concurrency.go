@@ -0,0 +1,104 @@
+package gqltesting
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// An execFunc executes test, in process or through some transport.
+type execFunc func(ctx context.Context, test *Test, variables map[string]interface{}) (*graphql.Response, error)
+
+func schemaExec(ctx context.Context, test *Test, variables map[string]interface{}) (*graphql.Response, error) {
+	return test.Schema.Exec(ctx, test.Query, test.OperationName, variables), nil
+}
+
+// preparedExec returns an execFunc that prepares the query of the test on its first call and then
+// reuses it, see WithPreparedQuery. A syntax error is returned as the response, as Exec does.
+func preparedExec() execFunc {
+	var once sync.Once
+	var q *graphql.PreparedQuery
+	var qErr *errors.QueryError
+	return func(ctx context.Context, test *Test, variables map[string]interface{}) (*graphql.Response, error) {
+		once.Do(func() {
+			q, qErr = test.Schema.Prepare(test.Query)
+		})
+		if qErr != nil {
+			return &graphql.Response{Errors: []*errors.QueryError{qErr}}, nil
+		}
+		return q.Exec(ctx, test.OperationName, variables), nil
+	}
+}
+
+// execConcurrently executes test n times in parallel and returns the results in iteration order,
+// or the first error exec returned.
+func execConcurrently(ctx context.Context, exec execFunc, test *Test, variables map[string]interface{}, n int) ([]*graphql.Response, error) {
+	if n < 1 {
+		n = 1
+	}
+	results := make([]*graphql.Response, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = exec(ctx, test, variables)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// checkIterations reports the iterations of a test run WithConcurrency whose data differs from
+// expected, or from the first iteration if expected is empty, or whose errors differ from those of
+// the first iteration.
+func checkIterations(t testing.TB, c *comparison, expected []byte, results []*graphql.Response) {
+	first := 0
+	if len(expected) == 0 {
+		expected = results[0].Data
+		first = 1
+	}
+
+	var diverged []int
+	var firstDiff string
+	for i := first; i < len(results); i++ {
+		diff := iterationDiff(c, expected, results[0], results[i])
+		if diff == "" {
+			continue
+		}
+		diverged = append(diverged, i+1)
+		if firstDiff == "" {
+			firstDiff = diff
+		}
+	}
+	if len(diverged) > 0 {
+		t.Errorf("iterations %v of %d diverged; first difference, in iteration %d:\n%s", diverged, len(results), diverged[0], firstDiff)
+	}
+}
+
+func iterationDiff(c *comparison, expected []byte, first, got *graphql.Response) string {
+	if diff := diffErrors(first.Errors, got.Errors, false, false); diff != "" {
+		return diff
+	}
+	if bytes.Equal(expected, got.Data) {
+		return ""
+	}
+	diff, ok, err := c.compare(expected, got.Data)
+	if err != nil {
+		return err.Error()
+	}
+	if !ok {
+		return diff
+	}
+	return ""
+}
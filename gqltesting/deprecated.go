@@ -0,0 +1,97 @@
+package gqltesting
+
+import (
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// AssertNoDeprecatedFields validates query against schema and fails the test for every selection,
+// in any operation or fragment it uses, of a field marked @deprecated in schema, reporting where
+// the field is selected along with the deprecation reason. It keeps example and integration
+// queries current as fields are deprecated. Only the document is validated, so operations with
+// required variables are checked without giving them values.
+func AssertNoDeprecatedFields(t *testing.T, schema *graphql.Schema, query string) {
+	t.Helper()
+	checkNoDeprecatedFields(t, schema, query)
+}
+
+func checkNoDeprecatedFields(t testing.TB, schema *graphql.Schema, query string) {
+	t.Helper()
+	doc, errs := ParseAndValidate(schema, query)
+	if len(errs) > 0 {
+		t.Fatalf("query is invalid: %s", errorMessages(errs))
+	}
+
+	w := &deprecationWalker{t: t, schema: schema, fragments: make(map[string]*QueryFragment), reported: make(map[string]bool)}
+	for _, frag := range doc.Fragments {
+		w.fragments[frag.Name] = frag
+	}
+	inspected := schema.Inspect()
+	for _, op := range doc.Operations {
+		root := inspected.QueryType()
+		switch op.Type {
+		case "mutation":
+			root = inspected.MutationType()
+		case "subscription":
+			root = inspected.SubscriptionType()
+		}
+		w.walk(*root.Name(), op.Selections, nil, make(map[string]bool))
+	}
+}
+
+type deprecationWalker struct {
+	t         testing.TB
+	schema    *graphql.Schema
+	fragments map[string]*QueryFragment
+	reported  map[string]bool // selection paths already reported, as fragments may be spread repeatedly
+}
+
+// walk checks the selections made on the type typeName at the response path p. spread holds the
+// fragments being expanded, which validation guarantees to be acyclic but which is cheap to guard.
+func (w *deprecationWalker) walk(typeName string, sels []*QuerySelection, p []string, spread map[string]bool) {
+	w.t.Helper()
+	for _, sel := range sels {
+		switch sel.Kind {
+		case FieldSelection:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			fieldPath := append(append([]string(nil), p...), sel.Alias)
+			f := lookupField(w.t, w.schema, typeName, sel.Name)
+			if key := strings.Join(fieldPath, "."); f.IsDeprecated() && !w.reported[key] {
+				w.reported[key] = true
+				reason := defaultDeprecationReason
+				if r := f.DeprecationReason(); r != nil {
+					reason = *r
+				}
+				w.t.Errorf("%s: %s.%s is deprecated: %s", key, typeName, sel.Name, reason)
+			}
+			w.walk(namedType(f.Type()), sel.Selections, fieldPath, spread)
+		case InlineFragmentSelection:
+			on := typeName
+			if sel.TypeCondition != "" {
+				on = sel.TypeCondition
+			}
+			w.walk(on, sel.Selections, p, spread)
+		case FragmentSpreadSelection:
+			frag := w.fragments[sel.Name]
+			if frag == nil || spread[sel.Name] {
+				continue
+			}
+			spread[sel.Name] = true
+			w.walk(frag.TypeCondition, frag.Selections, p, spread)
+			delete(spread, sel.Name)
+		}
+	}
+}
+
+// namedType returns the name of t once unwrapped from its list and non-null modifiers.
+func namedType(t *introspection.Type) string {
+	for t.OfType() != nil {
+		t = t.OfType()
+	}
+	return *t.Name()
+}
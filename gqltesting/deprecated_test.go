@@ -0,0 +1,60 @@
+package gqltesting
+
+import (
+	"reflect"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+func TestCheckNoDeprecatedFields(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			user: User
+			hello: String! @deprecated(reason: "Use greet instead.")
+			greet: String!
+			greetName(name: String!): String!
+		}
+
+		type User {
+			name: String!
+			login: String! @deprecated
+		}
+	`, nil)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{
+			query: `{ greet user { name } }`,
+		},
+		{
+			query: `query($name: String!) { greetName(name: $name) }`,
+		},
+		{
+			query: `query($name: String!) { greetName(name: $name) hello }`,
+			want:  []string{"hello: Query.hello is deprecated: Use greet instead."},
+		},
+		{
+			query: `{ hello user { name } }`,
+			want:  []string{"hello: Query.hello is deprecated: Use greet instead."},
+		},
+		{
+			query: `{ me: user { ...names ... on User { login } } }  fragment names on User { name handle: login }`,
+			want: []string{
+				"me.handle: User.login is deprecated: No longer supported",
+				"me.login: User.login is deprecated: No longer supported",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tb := runFake(func(tb testing.TB) {
+			checkNoDeprecatedFields(tb, schema, tt.query)
+		})
+		if !reflect.DeepEqual(tb.output, tt.want) {
+			t.Errorf("%s: got failures %q, want %q", tt.query, tb.output, tt.want)
+		}
+	}
+}
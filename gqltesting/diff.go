@@ -0,0 +1,288 @@
+package gqltesting
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DiffFunc computes a human-readable diff between the expected and actual JSON documents. It is
+// used to report mismatches in RunTest and friends. It defaults to a pure-Go line diff with
+// "expected"/"actual" labels; setting the GQLTESTING_DIFF environment variable to "system" selects
+// the diff binary found on the PATH instead.
+var DiffFunc func(expected, got []byte) (string, error) = defaultDiffFunc()
+
+func defaultDiffFunc() func(expected, got []byte) (string, error) {
+	if os.Getenv("GQLTESTING_DIFF") == "system" {
+		return systemDiff
+	}
+	return lineDiff
+}
+
+// DiffContext is the number of unchanged lines shown around each change by the diffs of DiffFunc,
+// as with diff -U. Longer runs of unchanged lines, such as the untouched elements of a large list,
+// are elided between "@@" hunk headers. A negative value shows every line.
+var DiffContext = 3
+
+// lineDiff returns a unified-diff-style comparison of expected and got, line by line, in hunks
+// with DiffContext lines of context.
+func lineDiff(expected, got []byte) (string, error) {
+	a := splitLines(expected)
+	b := splitLines(got)
+
+	// Trim the common prefix and suffix so the LCS table only covers the changed region.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []string
+	for _, line := range a[:prefix] {
+		ops = append(ops, " "+line)
+	}
+	ops = append(ops, lcsDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, " "+line)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("--- expected\n+++ actual\n")
+	writeHunks(&buf, ops, DiffContext)
+	return buf.String(), nil
+}
+
+// writeHunks writes the edit script ops as hunks holding context unchanged lines around the
+// changes, each preceded by a "@@ -l,s +l,s @@" header. Changes separated by at most twice as many
+// unchanged lines share a hunk. With a negative context, all of ops is written as a single hunk.
+func writeHunks(buf *bytes.Buffer, ops []string, context int) {
+	if context < 0 {
+		context = len(ops)
+	}
+	aLine, bLine := 1, 1 // line numbers of ops[i] in each document
+	for i := 0; i < len(ops); {
+		// Skip to the first change, keeping up to context lines before it.
+		first := i
+		for first < len(ops) && ops[first][0] == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			return
+		}
+		start := first - context
+		if start < i {
+			start = i
+		}
+		aLine += start - i
+		bLine += start - i
+
+		// Extend the hunk while the next change is close enough.
+		end := first
+		for unchanged := 0; end < len(ops) && unchanged <= 2*context; end++ {
+			if ops[end][0] == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > first && ops[end-1][0] == ' ' {
+			end--
+		}
+		if end += context; end > len(ops) {
+			end = len(ops)
+		}
+
+		var aCount, bCount int
+		for _, op := range ops[start:end] {
+			if op[0] != '+' {
+				aCount++
+			}
+			if op[0] != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, op := range ops[start:end] {
+			buf.WriteString(op + "\n")
+		}
+		aLine += aCount
+		bLine += bCount
+		i = end
+	}
+}
+
+// hunkRange formats the range of count lines starting at line like diff -u.
+func hunkRange(line, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line-1)
+	case 1:
+		return fmt.Sprintf("%d", line)
+	default:
+		return fmt.Sprintf("%d,%d", line, count)
+	}
+}
+
+// lcsDiff returns the edit script turning a into b, with each line prefixed by ' ', '-' or '+'.
+func lcsDiff(a, b []string) []string {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []string
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, "-"+a[i])
+			i++
+		default:
+			ops = append(ops, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, "-"+a[i])
+	}
+	for ; j < m; j++ {
+		ops = append(ops, "+"+b[j])
+	}
+	return ops
+}
+
+func splitLines(data []byte) []string {
+	s := strings.TrimSuffix(string(data), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// colorEnabled reports whether diffs are printed in color: removed lines in red and added lines
+// in green. Colors are used when standard output is a terminal and the NO_COLOR environment
+// variable is unset, and can be turned off for a test with WithoutColor.
+var colorEnabled = os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorReset = "\x1b[0m"
+)
+
+// colorizeDiff highlights the removed and added lines of a unified diff.
+func colorizeDiff(diff string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		text := strings.TrimSuffix(line, "\n")
+		var color string
+		switch {
+		case strings.HasPrefix(text, "---"), strings.HasPrefix(text, "+++"):
+		case strings.HasPrefix(text, "-"):
+			color = colorRed
+		case strings.HasPrefix(text, "+"):
+			color = colorGreen
+		}
+		if color == "" {
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(color + text + colorReset + line[len(text):])
+	}
+	return b.String()
+}
+
+// DiffTempDir is the directory in which the files compared by the system diff, and those kept by
+// KeepDiffFiles, are created, for environments where the default temporary directory is restricted.
+// If empty, the default directory for temporary files is used, see os.TempDir.
+var DiffTempDir string
+
+// KeepDiffFiles, if set, keeps the expected and actual results of every failed comparison as the
+// files "expected" and "actual" of a new directory under DiffTempDir, which the diff names, so
+// that CI can collect them as artifacts.
+var KeepDiffFiles bool
+
+// writeDiffFiles writes the files "expected" and "actual" to a new directory under DiffTempDir and
+// returns the directory.
+func writeDiffFiles(expected, got []byte) (string, error) {
+	dir, err := ioutil.TempDir(DiffTempDir, "gqltesting")
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "expected"), expected, 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "actual"), got, 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+var (
+	checkDiffOnce         sync.Once
+	diffAvailableOnSystem bool
+)
+
+// systemDiff shells out to the diff binary, falling back to lineDiff when it is not installed.
+func systemDiff(expected, got []byte) (string, error) {
+	checkDiffOnce.Do(func() {
+		_, err := exec.LookPath("diff")
+		diffAvailableOnSystem = err == nil
+	})
+	if !diffAvailableOnSystem {
+		return lineDiff(expected, got)
+	}
+
+	dir, err := writeDiffFiles(expected, got)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	expectedFile, actualFile := filepath.Join(dir, "expected"), filepath.Join(dir, "actual")
+
+	unified := "-U" + strconv.Itoa(DiffContext)
+	if DiffContext < 0 {
+		unified = "-U" + strconv.Itoa(len(expected)+len(got))
+	}
+	out, err := exec.Command("diff", unified, "--label", "expected", "--label", "actual", expectedFile, actualFile).CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		// Exit status 1 means the inputs differ, which is what we expect here.
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("diff: %s: %s", err, out)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,134 @@
+package gqltesting
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		got      string
+		want     string
+	}{
+		{
+			name:     "changed line",
+			expected: "{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+			got:      "{\n  \"a\": 1,\n  \"b\": 3\n}\n",
+			want:     "--- expected\n+++ actual\n@@ -1,4 +1,4 @@\n {\n   \"a\": 1,\n-  \"b\": 2\n+  \"b\": 3\n }\n",
+		},
+		{
+			name:     "added line",
+			expected: "[\n  1\n]\n",
+			got:      "[\n  1,\n  2\n]\n",
+			want:     "--- expected\n+++ actual\n@@ -1,3 +1,4 @@\n [\n-  1\n+  1,\n+  2\n ]\n",
+		},
+		{
+			name:     "empty expected",
+			expected: "",
+			got:      "null\n",
+			want:     "--- expected\n+++ actual\n@@ -0,0 +1 @@\n+null\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := lineDiff([]byte(tt.expected), []byte(tt.got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", diff, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineDiff_hunks(t *testing.T) {
+	lines := func(from, to int, changed map[int]string) string {
+		var b strings.Builder
+		for i := from; i <= to; i++ {
+			if s, ok := changed[i]; ok {
+				b.WriteString(s + "\n")
+			} else {
+				fmt.Fprintf(&b, "%d\n", i)
+			}
+		}
+		return b.String()
+	}
+	expected := lines(1, 30, nil)
+	got := lines(1, 30, map[int]string{5: "five", 12: "twelve", 25: "twenty-five"})
+
+	tests := []struct {
+		context int
+		want    string
+	}{
+		{
+			context: 3,
+			want: "@@ -2,14 +2,14 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n 9\n 10\n 11\n-12\n+twelve\n 13\n 14\n 15\n" +
+				"@@ -22,7 +22,7 @@\n 22\n 23\n 24\n-25\n+twenty-five\n 26\n 27\n 28\n",
+		},
+		{
+			context: 0,
+			want:    "@@ -5 +5 @@\n-5\n+five\n@@ -12 +12 @@\n-12\n+twelve\n@@ -25 +25 @@\n-25\n+twenty-five\n",
+		},
+	}
+
+	defer func(context int) { DiffContext = context }(DiffContext)
+	for _, tt := range tests {
+		DiffContext = tt.context
+		diff, err := lineDiff([]byte(expected), []byte(got))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "--- expected\n+++ actual\n" + tt.want; diff != want {
+			t.Errorf("context %d: got:\n%s\nwant:\n%s", tt.context, diff, want)
+		}
+	}
+
+	DiffContext = -1
+	diff, err := lineDiff([]byte(expected), []byte(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "@@ -1,30 +1,30 @@\n 1\n") || !strings.HasSuffix(diff, " 30\n") {
+		t.Errorf("got %q, want every line with a negative context", diff)
+	}
+}
+
+func TestColorizeDiff(t *testing.T) {
+	diff := "--- expected\n+++ actual\n {\n-  \"a\": 1\n+  \"a\": 2\n }\n"
+	want := "--- expected\n+++ actual\n {\n\x1b[31m-  \"a\": 1\x1b[0m\n\x1b[32m+  \"a\": 2\x1b[0m\n }\n"
+	if got := colorizeDiff(diff); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDiffJSON_keepDiffFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqltesting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { DiffTempDir, KeepDiffFiles = "", false }()
+	DiffTempDir, KeepDiffFiles = dir, true
+
+	diff := diffJSON([]byte(`{"a":1}`), []byte(`{"a":2}`))
+	kept, err := filepath.Glob(filepath.Join(dir, "gqltesting*"))
+	if err != nil || len(kept) != 1 {
+		t.Fatalf("got kept directories %q, want one", kept)
+	}
+	if want := "kept in " + kept[0] + "\n"; !strings.HasSuffix(diff, want) {
+		t.Errorf("diff %q does not end with %q", diff, want)
+	}
+	for name, want := range map[string]string{"expected": "{\n  \"a\": 1\n}\n", "actual": "{\n  \"a\": 2\n}\n"} {
+		if got, err := ioutil.ReadFile(filepath.Join(kept[0], name)); err != nil || string(got) != want {
+			t.Errorf("%s: got %q (%v), want %q", name, got, err, want)
+		}
+	}
+}
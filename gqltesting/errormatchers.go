@@ -0,0 +1,55 @@
+package gqltesting
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// An ErrorMatcher asserts something about the errors returned by an operation, returning a
+// descriptive error if they do not match. Setting Test.ErrorMatcher replaces the default
+// comparison of errors entirely.
+type ErrorMatcher interface {
+	Match(got []*errors.QueryError) error
+}
+
+// ErrorMatcherFunc is an adapter to allow the use of ordinary functions as an ErrorMatcher.
+type ErrorMatcherFunc func(got []*errors.QueryError) error
+
+// Match calls f(got).
+func (f ErrorMatcherFunc) Match(got []*errors.QueryError) error {
+	return f(got)
+}
+
+// ExactErrors matches exactly the given errors, in any order, like Test.ExpectedErrors.
+func ExactErrors(want ...*errors.QueryError) ErrorMatcher {
+	return ErrorMatcherFunc(func(got []*errors.QueryError) error {
+		if len(got) == 0 && len(want) == 0 {
+			return nil
+		}
+		if diff := diffErrors(want, got, false, false); diff != "" {
+			return fmt.Errorf("%s", diff)
+		}
+		return nil
+	})
+}
+
+// AnyError matches as long as at least one error was returned.
+func AnyError() ErrorMatcher {
+	return ErrorMatcherFunc(func(got []*errors.QueryError) error {
+		if len(got) == 0 {
+			return fmt.Errorf("got no errors, want at least one")
+		}
+		return nil
+	})
+}
+
+// ErrorCount matches if exactly n errors were returned.
+func ErrorCount(n int) ErrorMatcher {
+	return ErrorMatcherFunc(func(got []*errors.QueryError) error {
+		if len(got) != n {
+			return fmt.Errorf("got %d errors, want %d: %s", len(got), n, errorMessages(got))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,382 @@
+package gqltesting
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// checkValid fails the test immediately if query does not pass validation against schema.
+func checkValid(t testing.TB, schema *graphql.Schema, query string, variables map[string]interface{}) {
+	errs := schema.ValidateWithVariables(query, variables)
+	if len(errs) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("query failed validation:")
+	for _, err := range errs {
+		fmt.Fprintf(&b, "\n\t%s", err)
+	}
+	t.Fatal(b.String())
+}
+
+// checkTestErrors verifies the errors returned by executing test against its expectations.
+func checkTestErrors(t testing.TB, test *Test, cfg *runConfig, got []*errors.QueryError) {
+	if test.ExpectedErrorCount != nil {
+		checkErrorCount(t, *test.ExpectedErrorCount, got)
+	}
+
+	if test.ErrorMatcher != nil {
+		if err := test.ErrorMatcher.Match(got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if test.ExpectedLimitError != "" {
+		checkLimitError(t, test.ExpectedLimitError, got)
+		return
+	}
+
+	if test.ExpectNoErrors {
+		checkNoErrors(t, got)
+		return
+	}
+
+	if len(test.ExpectedErrorsSubset) > 0 {
+		if len(test.ExpectedErrors) > 0 {
+			t.Fatal("ExpectedErrors and ExpectedErrorsSubset are mutually exclusive")
+		}
+		checkErrorsSubset(t, test.ExpectedErrorsSubset, got)
+	}
+
+	messageMatchers := len(test.ExpectedErrorsContain) > 0 || len(test.ExpectedErrorRegexps) > 0
+	switch {
+	case messageMatchers && len(test.ExpectedErrors) > 0:
+		checkErrorsIgnoringMessages(t, test.ExpectedErrors, got)
+	case messageMatchers, test.ExpectedErrorExtensions != nil && len(test.ExpectedErrors) == 0:
+		// The relaxed matchers below replace the strict comparison.
+	case test.ExpectedErrorCount != nil && test.ExpectedErrors == nil:
+		// Only the number of errors, already checked by checkErrorCount, is asserted.
+	case cfg.ignoreErrors && test.ExpectedErrors == nil, len(test.ExpectedErrorsSubset) > 0:
+		// Errors are allowed, see WithIgnoreErrors, or already checked by checkErrorsSubset.
+	default:
+		checkErrors(t, test.ExpectedErrors, got, cfg.strictErrors, cfg.orderedErrors)
+	}
+
+	for _, substr := range test.ExpectedErrorsContain {
+		if !anyErrorMessage(got, func(msg string) bool { return strings.Contains(msg, substr) }) {
+			t.Errorf("no error message contains %q; got %s", substr, errorMessages(got))
+		}
+	}
+	for _, re := range test.ExpectedErrorRegexps {
+		if !anyErrorMessage(got, re.MatchString) {
+			t.Errorf("no error message matches %q; got %s", re, errorMessages(got))
+		}
+	}
+	if test.ExpectedErrorExtensions != nil {
+		checkErrorExtensions(t, test.ExpectedErrorExtensions, got)
+	}
+}
+
+// MaxDepthExceeded is the validation rule of the errors reported for queries exceeding the
+// graphql.MaxDepth of the schema, for use with Test.ExpectedLimitError.
+const MaxDepthExceeded = "MaxDepthExceeded"
+
+// checkLimitError fails the test unless got includes an error reported by the given rule.
+func checkLimitError(t testing.TB, rule string, got []*errors.QueryError) {
+	for _, err := range got {
+		if err.Rule == rule {
+			return
+		}
+	}
+	t.Fatalf("got errors %s, want a %s limit error", errorMessages(got), rule)
+}
+
+// checkNoErrors fails the test if any errors were returned, listing each of them.
+func checkNoErrors(t testing.TB, got []*errors.QueryError) {
+	if len(got) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "expected no errors, got %d:", len(got))
+	listErrors(&b, got)
+	t.Fatal(b.String())
+}
+
+// checkErrorCount fails the test unless exactly want errors were returned, listing each of them.
+func checkErrorCount(t testing.TB, want int, got []*errors.QueryError) {
+	if len(got) == want {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "got %d errors, want %d", len(got), want)
+	if len(got) > 0 {
+		b.WriteString(":")
+		listErrors(&b, got)
+	}
+	t.Error(b.String())
+}
+
+// listErrors writes the message of each error, along with its path if any, on a line of its own.
+func listErrors(b *strings.Builder, errs []*errors.QueryError) {
+	for _, err := range errs {
+		fmt.Fprintf(b, "\n\t%s", err.Message)
+		if len(err.Path) > 0 {
+			fmt.Fprintf(b, " (path: %v)", err.Path)
+		}
+	}
+}
+
+func checkErrors(t testing.TB, want, got []*errors.QueryError, strict, ordered bool) {
+	if diff := diffErrors(want, got, strict, ordered); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+// diffErrors describes how got differs from want after both are sorted by path, unless ordered is
+// set, or returns "" if they are equal. Unless strict is set, numeric path segments are compared
+// by value regardless of their concrete Go type, so that an expected index of float64(1) matches
+// an actual index of int(1).
+func diffErrors(want, got []*errors.QueryError, strict, ordered bool) string {
+	if !ordered {
+		want, got = sortedErrors(want), sortedErrors(got)
+	}
+
+	if strict || len(got) != len(want) {
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Sprintf("unexpected error: got %+v, want %+v", got, want)
+		}
+		return ""
+	}
+
+	for i := range want {
+		if seg, ok := firstPathDifference(want[i].Path, got[i].Path); !ok {
+			return fmt.Sprintf("unexpected error %d: path %v differs from %v at segment %d", i, got[i].Path, want[i].Path, seg)
+		}
+		w, g := *want[i], *got[i]
+		w.Path, g.Path = nil, nil
+		if !reflect.DeepEqual(g, w) {
+			return fmt.Sprintf("unexpected error %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	return ""
+}
+
+// firstPathDifference returns the index of the first segment at which the paths differ, and
+// whether they are equal. Numeric segments are compared by value.
+func firstPathDifference(want, got []interface{}) (int, bool) {
+	for i := 0; i < len(want) && i < len(got); i++ {
+		wn, wok := toFloat(want[i])
+		gn, gok := toFloat(got[i])
+		if wok && gok {
+			if wn != gn {
+				return i, false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(want[i], got[i]) {
+			return i, false
+		}
+	}
+	if len(want) != len(got) {
+		if len(want) < len(got) {
+			return len(want), false
+		}
+		return len(got), false
+	}
+	return 0, true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// checkErrorsSubset fails the test for every error of want that does not match a distinct error
+// of got, as described on Test.ExpectedErrorsSubset.
+func checkErrorsSubset(t testing.TB, want, got []*errors.QueryError) {
+	got = sortedErrors(got)
+	matched := make([]bool, len(got))
+	for _, w := range sortedErrors(want) {
+		found := false
+		for i, g := range got {
+			if !matched[i] && errorMatches(w, g) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no error %q at path %v; got %s", w.Message, w.Path, errorMessages(got))
+		}
+	}
+}
+
+// errorMatches reports whether got matches want, comparing the optional fields only if want sets
+// them.
+func errorMatches(want, got *errors.QueryError) bool {
+	if want.Message != got.Message {
+		return false
+	}
+	if _, ok := firstPathDifference(want.Path, got.Path); !ok {
+		return false
+	}
+	if want.Locations != nil && !reflect.DeepEqual(want.Locations, got.Locations) {
+		return false
+	}
+	if want.Extensions != nil && !reflect.DeepEqual(want.Extensions, got.Extensions) {
+		return false
+	}
+	return want.Rule == "" || want.Rule == got.Rule
+}
+
+// checkErrorsIgnoringMessages compares only the Path and Extensions of each error.
+func checkErrorsIgnoringMessages(t testing.TB, want, got []*errors.QueryError) {
+	want, got = sortedErrors(want), sortedErrors(got)
+
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of errors: got %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if _, ok := firstPathDifference(want[i].Path, got[i].Path); !ok || !reflect.DeepEqual(got[i].Extensions, want[i].Extensions) {
+			t.Fatalf("unexpected error %d: got path %v, extensions %v, want path %v, extensions %v",
+				i, got[i].Path, got[i].Extensions, want[i].Path, want[i].Extensions)
+		}
+	}
+}
+
+// checkErrorExtensions compares the Extensions of each returned error, after sorting, against the
+// positionally matching entry of want, reporting every key that differs.
+func checkErrorExtensions(t testing.TB, want []map[string]interface{}, got []*errors.QueryError) {
+	got = sortedErrors(got)
+
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of errors: got %d, want %d extensions", len(got), len(want))
+	}
+	for i, ext := range want {
+		if diff := diffExtensions(ext, got[i].Extensions); len(diff) > 0 {
+			t.Errorf("error %d (%q): extensions differ: %s", i, got[i].Message, strings.Join(diff, "; "))
+		}
+	}
+}
+
+// diffExtensions describes every key whose value differs between want and got. A nil map and an
+// empty map are considered equal.
+func diffExtensions(want, got map[string]interface{}) []string {
+	keys := make(map[string]struct{}, len(want)+len(got))
+	for k := range want {
+		keys[k] = struct{}{}
+	}
+	for k := range got {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var diff []string
+	for _, k := range sorted {
+		w, wok := want[k]
+		g, gok := got[k]
+		switch {
+		case !gok:
+			diff = append(diff, fmt.Sprintf("%q: missing, want %v", k, w))
+		case !wok:
+			diff = append(diff, fmt.Sprintf("%q: got %v, want no such key", k, g))
+		case !reflect.DeepEqual(g, w):
+			diff = append(diff, fmt.Sprintf("%q: got %v, want %v", k, g, w))
+		}
+	}
+	return diff
+}
+
+func anyErrorMessage(errs []*errors.QueryError, match func(string) bool) bool {
+	for _, err := range errs {
+		if match(err.Message) {
+			return true
+		}
+	}
+	return false
+}
+
+func errorMessages(errs []*errors.QueryError) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = fmt.Sprintf("%q", err.Message)
+	}
+	return "[" + strings.Join(msgs, ", ") + "]"
+}
+
+// SortQueryErrors sorts errors in place by their path, as RunTest does before comparing them, so
+// that errors reported by resolvers running concurrently compare in a deterministic order. Numeric
+// path segments sort by value regardless of their concrete Go type, and errors with equal paths,
+// such as validation errors without a path, keep their relative order.
+func SortQueryErrors(errors []*errors.QueryError) {
+	if len(errors) <= 1 {
+		return
+	}
+	sort.SliceStable(errors, func(i, j int) bool {
+		return pathKey(errors[i].Path) < pathKey(errors[j].Path)
+	})
+}
+
+// sortedErrors returns a sorted copy of errs, leaving errs untouched so that tests sharing their
+// expected errors can run concurrently.
+func sortedErrors(errs []*errors.QueryError) []*errors.QueryError {
+	if errs == nil {
+		return nil
+	}
+	sorted := append([]*errors.QueryError(nil), errs...)
+	SortQueryErrors(sorted)
+	return sorted
+}
+
+// pathKey formats path for sorting, rendering numeric segments the same regardless of their type.
+func pathKey(path []interface{}) string {
+	segs := make([]string, len(path))
+	for i, seg := range path {
+		if n, ok := toFloat(seg); ok {
+			segs[i] = strconv.FormatFloat(n, 'f', -1, 64)
+		} else {
+			segs[i] = fmt.Sprint(seg)
+		}
+	}
+	return strings.Join(segs, ".")
+}
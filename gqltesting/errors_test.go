@@ -0,0 +1,116 @@
+package gqltesting
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+func TestDiffErrors_paths(t *testing.T) {
+	got := func() []*errors.QueryError {
+		return []*errors.QueryError{{Message: "boom", Path: []interface{}{"users", 1, "name"}}}
+	}
+
+	tests := []struct {
+		name   string
+		want   []*errors.QueryError
+		strict bool
+		diff   string
+	}{
+		{
+			name: "numeric types are interchangeable",
+			want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"users", float64(1), "name"}}},
+		},
+		{
+			name:   "strict requires identical types",
+			want:   []*errors.QueryError{{Message: "boom", Path: []interface{}{"users", float64(1), "name"}}},
+			strict: true,
+			diff:   "unexpected error: got",
+		},
+		{
+			name: "reports the differing segment",
+			want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"users", 2, "name"}}},
+			diff: "at segment 1",
+		},
+		{
+			name: "reports a missing segment",
+			want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"users", 1}}},
+			diff: "at segment 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffErrors(tt.want, got(), tt.strict, false)
+			if tt.diff == "" && diff != "" || !strings.Contains(diff, tt.diff) {
+				t.Errorf("got diff %q, want it to contain %q", diff, tt.diff)
+			}
+		})
+	}
+}
+
+func TestDiffErrors_ordered(t *testing.T) {
+	a := &errors.QueryError{Message: "a", Path: []interface{}{"b"}}
+	b := &errors.QueryError{Message: "b", Path: []interface{}{"a"}}
+
+	if diff := diffErrors([]*errors.QueryError{b, a}, []*errors.QueryError{a, b}, false, false); diff != "" {
+		t.Errorf("unordered comparison: unexpected diff %q", diff)
+	}
+	if diff := diffErrors([]*errors.QueryError{b, a}, []*errors.QueryError{a, b}, false, true); diff == "" {
+		t.Error("ordered comparison: errors in a different order compared equal")
+	}
+}
+
+func TestSortQueryErrors(t *testing.T) {
+	errs := []*errors.QueryError{
+		{Message: "c", Path: []interface{}{"users", float64(1)}},
+		{Message: "a"},
+		{Message: "b", Path: []interface{}{"users", 0}},
+		{Message: "d"},
+	}
+	SortQueryErrors(errs)
+
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Message)
+	}
+	if want := "a d b c"; strings.Join(got, " ") != want {
+		t.Errorf("got order %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestCheckErrorsSubset(t *testing.T) {
+	got := []*errors.QueryError{
+		{Message: "boom", Path: []interface{}{"a"}},
+		{Message: "boom", Path: []interface{}{"b"}, Rule: "SomeRule"},
+	}
+
+	tests := []struct {
+		name string
+		want []*errors.QueryError
+		fail bool
+	}{
+		{name: "one of several", want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"b"}}}},
+		{name: "rule", want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"b"}, Rule: "SomeRule"}}},
+		{name: "wrong path", want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"c"}}}, fail: true},
+		{name: "wrong rule", want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"a"}, Rule: "SomeRule"}}, fail: true},
+		{
+			name: "matched once",
+			want: []*errors.QueryError{{Message: "boom", Path: []interface{}{"a"}}, {Message: "boom", Path: []interface{}{"a"}}},
+			fail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := runFake(func(tb testing.TB) {
+				checkErrorsSubset(tb, tt.want, got)
+			})
+			if tb.Failed() != tt.fail {
+				t.Errorf("got failed %v, want %v; output:\n%s", tb.Failed(), tt.fail, tb.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package gqltesting
+
+import "testing"
+
+// RunFeatureFlagTest runs test twice, as the subtests "on" and "off", to cover both sides of a
+// feature flag read from the context, such as one consulted by an @feature(flag:) directive. The
+// flag is set to true and false respectively under flagKey with WithContextValue, and the data is
+// compared against expectedOn and expectedOff instead of test.ExpectedResult. Sharing the query
+// and the other expectations of test keeps both runs from drifting apart.
+func RunFeatureFlagTest(t *testing.T, test *Test, flagKey interface{}, expectedOn, expectedOff string, opts ...Option) {
+	for _, run := range []struct {
+		name     string
+		on       bool
+		expected string
+	}{
+		{"on", true, expectedOn},
+		{"off", false, expectedOff},
+	} {
+		variant := *test
+		variant.ExpectedResult = run.expected
+		runOpts := append(append([]Option(nil), opts...), WithContextValue(flagKey, run.on))
+		t.Run(run.name, func(t *testing.T) {
+			RunTest(t, &variant, runOpts...)
+		})
+	}
+}
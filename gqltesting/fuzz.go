@@ -0,0 +1,40 @@
+//go:build go1.18
+// +build go1.18
+
+package gqltesting
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// fuzzTimeout bounds the execution of each query generated by FuzzExec.
+const fuzzTimeout = time.Second
+
+// FuzzExec fuzzes query strings against schema, starting from the given seed queries. Each query
+// is executed with a short deadline, and must return a well-formed response, which serializes to
+// JSON with valid data, rather than panic. Errors in the response are expected and ignored.
+func FuzzExec(f *testing.F, schema *graphql.Schema, seeds []string) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, query string) {
+		ctx, cancel := context.WithTimeout(context.Background(), fuzzTimeout)
+		defer cancel()
+
+		result := schema.Exec(ctx, query, "", nil)
+		if result == nil {
+			t.Fatalf("query %q: got nil response", query)
+		}
+		if len(result.Data) > 0 && !json.Valid(result.Data) {
+			t.Fatalf("query %q: got invalid JSON data: %s", query, result.Data)
+		}
+		if _, err := json.Marshal(result); err != nil {
+			t.Fatalf("query %q: serializing response: %s", query, err)
+		}
+	})
+}
@@ -0,0 +1,19 @@
+//go:build go1.18
+// +build go1.18
+
+package gqltesting_test
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+)
+
+func FuzzExec(f *testing.F) {
+	gqltesting.FuzzExec(f, helloSchema, []string{
+		`{ hello }`,
+		`query($name: String!) { greet(name: $name) }`,
+		`{ users { id name } fail }`,
+		`{ __schema { types { name } } }`,
+	})
+}
@@ -0,0 +1,147 @@
+package gqltesting
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+var update = flag.Bool("update", false, "rewrite gqltesting golden files with the actual results")
+
+// UpdateEnv is the environment variable that, set to a non-empty value, has the same effect as the
+// -update flag: every golden file that does not match the actual result, namely those of
+// Test.GoldenFile, Test.FixtureFile, RunIntrospectionSnapshot and AssertSDL, is rewritten with it
+// instead of failing the test. Unlike the flag, it also applies when go test runs packages that do
+// not define -update. The cassettes of WithCassette are recorded with RecordEnv instead.
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// updating reports whether golden files are to be rewritten, see UpdateEnv.
+func updating() bool {
+	return *update || os.Getenv(UpdateEnv) != ""
+}
+
+// goldenResult returns the expected JSON stored in path. When updating golden files, the file is
+// first rewritten with the formatted actual data unless it already holds the same document.
+func goldenResult(t testing.TB, path string, data []byte) []byte {
+	if updating() {
+		formatted, err := FormatJSON(data)
+		if err != nil {
+			t.Fatalf("got: %s", invalidJSON(err, data))
+		}
+		updateGolden(t, path, indentJSON(formatted), normalizeJSON)
+		return formatted
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %s (run with -update or %s=1 to create it)", err, UpdateEnv)
+	}
+	return want
+}
+
+// updateGolden rewrites the golden file at path with content and logs it for review, see
+// writeGolden.
+func updateGolden(t testing.TB, path string, content []byte, normalize func([]byte) []byte) {
+	written, err := writeGolden(path, content, normalize)
+	if err != nil {
+		t.Fatalf("updating golden file: %s", err)
+	}
+	if written {
+		t.Logf("updated golden file %s", path)
+	}
+}
+
+// writeGolden rewrites the file at path with content and reports whether it did. A file already
+// holding content, after applying normalize to it if not nil, is left untouched so that updating
+// only shows actual changes, such as for a JSON document formatted differently.
+func writeGolden(path string, content []byte, normalize func([]byte) []byte) (bool, error) {
+	if old, err := ioutil.ReadFile(path); err == nil {
+		if normalize != nil {
+			old = normalize(old)
+		}
+		if bytes.Equal(old, content) {
+			return false, nil
+		}
+	}
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// normalizeJSON formats the JSON document data the way golden files are written, indented, leaving
+// it as is if it is not valid JSON.
+func normalizeJSON(data []byte) []byte {
+	formatted, err := FormatJSON(data)
+	if err != nil {
+		return data
+	}
+	return indentJSON(formatted)
+}
+
+// A fixture is the content of a Test.FixtureFile: the envelope of a response.
+type fixture struct {
+	Data   json.RawMessage      `json:"data,omitempty"`
+	Errors []*errors.QueryError `json:"errors,omitempty"`
+}
+
+// fixtureResult returns the expected data and errors stored in the fixture at path. When updating
+// golden files, the fixture is first rewritten with result, unless it already holds the same
+// response.
+func fixtureResult(t testing.TB, path string, result *graphql.Response) ([]byte, []*errors.QueryError) {
+	if updating() {
+		data, err := json.Marshal(&fixture{Data: result.Data, Errors: result.Errors})
+		if err != nil {
+			t.Fatalf("got: %s", err)
+		}
+		formatted, err := FormatJSON(data)
+		if err != nil {
+			t.Fatalf("got: %s", invalidJSON(err, data))
+		}
+		updateGolden(t, path, indentJSON(formatted), normalizeJSON)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading fixture: %s (run with -update or %s=1 to create it)", err, UpdateEnv)
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("fixture %s: %s", path, err)
+	}
+	return f.Data, f.Errors
+}
+
+// checkFixtureErrors compares the errors of a fixture with those returned, both sorted by path, as
+// JSON, since fixtures capture responses as clients receive them. Placeholders are supported.
+func checkFixtureErrors(t testing.TB, cfg *runConfig, want, got []*errors.QueryError) {
+	wantJSON, err := errorsJSON(want)
+	if err != nil {
+		t.Fatalf("want: %s", err)
+	}
+	gotJSON, err := errorsJSON(got)
+	if err != nil {
+		t.Fatalf("got: %s", err)
+	}
+	diff, ok, err := (&comparison{noColor: cfg.noColor, placeholder: cfg.placeholder}).compare(wantJSON, gotJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("errors differ from the fixture:\n%s", diff)
+	}
+}
+
+// errorsJSON encodes errs sorted by path, or null if there are none.
+func errorsJSON(errs []*errors.QueryError) ([]byte, error) {
+	if len(errs) == 0 {
+		return []byte("null"), nil
+	}
+	return json.Marshal(sortedErrors(errs))
+}
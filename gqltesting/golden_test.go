@@ -0,0 +1,52 @@
+package gqltesting
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoldenResult_update(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqltesting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "golden.json")
+	if err := ioutil.WriteFile(path, []byte(`{"hello": "Hello!"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(UpdateEnv, "1")
+	defer os.Unsetenv(UpdateEnv)
+
+	tb := runFake(func(tb testing.TB) {
+		goldenResult(tb, path, []byte(`{"hello": "Hello world!"}`))
+	})
+	if tb.Failed() || !strings.Contains(tb.String(), "updated golden file "+path) {
+		t.Fatalf("got output %q, want the update to be logged", tb.String())
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"hello\": \"Hello world!\"\n}\n"; string(got) != want {
+		t.Errorf("got golden file %q, want %q", got, want)
+	}
+
+	// A golden file holding the same document is left untouched.
+	if err := ioutil.WriteFile(path, []byte(`{"hello":"Hello world!"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tb = runFake(func(tb testing.TB) {
+		goldenResult(tb, path, []byte(`{"hello": "Hello world!"}`))
+	})
+	if tb.Failed() || len(tb.output) > 0 {
+		t.Errorf("got output %q, want no update", tb.String())
+	}
+	if got, _ := ioutil.ReadFile(path); string(got) != `{"hello":"Hello world!"}` {
+		t.Errorf("golden file rewritten to %q", got)
+	}
+}
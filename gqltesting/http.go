@@ -0,0 +1,72 @@
+package gqltesting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// RunTestHTTP runs a GraphQL test case, serializes the whole response the way relay.Handler does,
+// with its "data", "errors" and "extensions" members, and compares it against
+// test.ExpectedResponse. The envelope is compared structurally, like ExpectedResult, except that
+// its members must also appear in the same order. This checks the serialization rules, such as
+// "errors" being omitted when there are none, that RunTest cannot observe because it inspects the
+// data and errors separately. The other expectations of test are ignored.
+func RunTestHTTP(t *testing.T, test *Test, opts ...Option) {
+	cfg := newRunConfig(opts)
+	if cfg.parallel {
+		t.Parallel()
+	}
+	if test.ExpectedResponse == "" {
+		t.Fatal("RunTestHTTP requires ExpectedResponse")
+	}
+
+	result := execTest(t, test, cfg, testVariables(t, test))
+	resp, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &comparison{useNumber: cfg.useNumber, floatTolerance: cfg.floatTolerance, noColor: cfg.noColor, placeholder: cfg.placeholder}
+	checkData(t, c, []byte(test.ExpectedResponse), resp)
+	if t.Failed() {
+		return
+	}
+
+	want, err := memberNames([]byte(test.ExpectedResponse))
+	if err != nil {
+		t.Fatalf("want: invalid JSON: %s", err)
+	}
+	got, err := memberNames(resp)
+	if err != nil {
+		t.Fatalf("got: %s", invalidJSON(err, resp))
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got response members %q, want %q", got, want)
+	}
+}
+
+// memberNames returns the names of the members of the JSON object data, in order.
+func memberNames(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object, got %v", tok)
+	}
+
+	var names []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, tok.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,96 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// An IncrementalPayload is one payload of a response delivered incrementally, as with the @defer
+// and @stream directives: the initial result or a subsequent patch of the data at Path.
+type IncrementalPayload struct {
+	Data    json.RawMessage      `json:"data,omitempty"`
+	Path    []interface{}        `json:"path,omitempty"`
+	Label   string               `json:"label,omitempty"`
+	Errors  []*errors.QueryError `json:"errors,omitempty"`
+	HasNext bool                 `json:"hasNext"`
+}
+
+// An ExpectedPayload describes an IncrementalPayload expected by RunIncrementalTest. Data is
+// compared like Test.ExpectedResult, Errors like Test.ExpectedErrors, and Path like error paths.
+type ExpectedPayload struct {
+	Data    string
+	Path    []interface{}
+	Label   string
+	Errors  []*errors.QueryError
+	HasNext bool
+}
+
+// RunIncrementalTest receives every payload of an incrementally delivered response from payloads
+// and compares them, in order, against expected: the initial payload followed by the patches. The
+// last payload must have HasNext unset, and the channel must then be closed.
+//
+// The executor of this package does not implement @defer and @stream yet, so payloads come from
+// an external source, such as a gateway client; the helper standardizes how deferred fields are
+// tested until the executor provides an incremental channel of its own. The context and timeout
+// options apply as for RunTest, bounding how long the test waits for the payloads.
+func RunIncrementalTest(t *testing.T, payloads <-chan *IncrementalPayload, expected []ExpectedPayload, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	if len(expected) == 0 {
+		t.Fatal("RunIncrementalTest requires at least the initial payload")
+	}
+	if expected[len(expected)-1].HasNext {
+		t.Fatal("the last expected payload must have HasNext unset")
+	}
+
+	parent := testContext(&Test{}, cfg)
+	ctx, cancel := timeoutContext(parent, testTimeout(parent, cfg))
+	defer cancel()
+	c := &comparison{useNumber: cfg.useNumber, floatTolerance: cfg.floatTolerance, noColor: cfg.noColor, placeholder: cfg.placeholder}
+
+	for i, want := range expected {
+		var got *IncrementalPayload
+		var ok bool
+		select {
+		case got, ok = <-payloads:
+		case <-ctx.Done():
+			t.Fatalf("payload %d: %s while waiting for it", i+1, ctx.Err())
+		}
+		if !ok {
+			t.Fatalf("payloads closed after %d payloads, want %d", i, len(expected))
+		}
+
+		if diff := diffErrors(want.Errors, got.Errors, cfg.strictErrors, cfg.orderedErrors); diff != "" {
+			t.Errorf("payload %d: %s", i+1, diff)
+		}
+		if _, ok := firstPathDifference(want.Path, got.Path); !ok {
+			t.Errorf("payload %d: got path %v, want %v", i+1, got.Path, want.Path)
+		}
+		if got.Label != want.Label {
+			t.Errorf("payload %d: got label %q, want %q", i+1, got.Label, want.Label)
+		}
+		if got.HasNext != want.HasNext {
+			t.Errorf("payload %d: got hasNext %v, want %v", i+1, got.HasNext, want.HasNext)
+		}
+		if want.Data != "" || len(got.Data) > 0 {
+			diff, ok, err := c.compare([]byte(want.Data), got.Data)
+			if err != nil {
+				t.Fatalf("payload %d: %s", i+1, err)
+			}
+			if !ok {
+				t.Errorf("payload %d: diff:\n%s", i+1, diff)
+			}
+		}
+	}
+
+	select {
+	case got, ok := <-payloads:
+		if ok {
+			t.Fatalf("unexpected payload after the final one: %+v", got)
+		}
+	case <-ctx.Done():
+		t.Fatalf("payloads not closed after the final one: %s", ctx.Err())
+	}
+}
@@ -0,0 +1,203 @@
+package gqltesting
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/trace"
+)
+
+// Instrument wraps tracer so that tests can observe resolver invocations, as needed by
+// Test.MaxResolverCalls, Test.ExpectedResolutionOrder, Test.MaxFieldDuration and
+// RunTestWithTrace. If tracer is nil, trace.NoopTracer is wrapped. Install it when building the
+// schema under test:
+//
+//	schema := graphql.MustParseSchema(sdl, resolver, graphql.Tracer(gqltesting.Instrument(nil)))
+//
+// Outside of this package's helpers, the wrapped tracer behaves exactly like tracer.
+func Instrument(tracer trace.Tracer) trace.Tracer {
+	if tracer == nil {
+		tracer = trace.NoopTracer{}
+	}
+	return instrumentedTracer{tracer}
+}
+
+// CountResolverCalls is equivalent to Instrument.
+func CountResolverCalls(tracer trace.Tracer) trace.Tracer {
+	return Instrument(tracer)
+}
+
+type instrumentedTracer struct {
+	trace.Tracer
+}
+
+func (t instrumentedTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, trace.TraceFieldFinishFunc) {
+	field := typeName + "." + fieldName
+	if calls, ok := ctx.Value(resolverCallsKey{}).(*resolverCalls); ok {
+		calls.add(field)
+	}
+
+	ctx, finish := t.Tracer.TraceField(ctx, label, typeName, fieldName, trivial, args)
+	tr, ok := ctx.Value(traceRecorderKey{}).(*traceRecorder)
+	if !ok {
+		return ctx, finish
+	}
+	start := tr.now()
+	return ctx, func(err *errors.QueryError) {
+		tr.add(&ResolverTrace{
+			ParentType:  typeName,
+			FieldName:   fieldName,
+			StartOffset: start.Sub(tr.trace.StartTime),
+			Duration:    tr.now().Sub(start),
+			Err:         err,
+		})
+		finish(err)
+	}
+}
+
+type resolverCallsKey struct{}
+
+// resolverCalls counts resolver invocations, keyed by "Type.field", and records their order.
+type resolverCalls struct {
+	mu     sync.Mutex
+	counts map[string]int
+	order  []string
+}
+
+func withResolverCalls(ctx context.Context) (context.Context, *resolverCalls) {
+	calls := &resolverCalls{counts: make(map[string]int)}
+	return context.WithValue(ctx, resolverCallsKey{}, calls), calls
+}
+
+func (c *resolverCalls) add(field string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[field]++
+	c.order = append(c.order, field)
+}
+
+// check fails the test for every field that was resolved more often than allowed by max.
+func (c *resolverCalls) check(t testing.TB, max map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkRecorded(t)
+	for _, field := range sortedKeys(max) {
+		if got := c.counts[field]; got > max[field] {
+			t.Errorf("%s resolved %d times, want at most %d", field, got, max[field])
+		}
+	}
+}
+
+// checkOrder fails the test unless the fields named in want were resolved in exactly that order.
+// Invocations of other fields, such as the fields of the objects returned by a mutation, are not
+// considered.
+func (c *resolverCalls) checkOrder(t testing.TB, want []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkRecorded(t)
+	considered := make(map[string]bool, len(want))
+	for _, field := range want {
+		considered[field] = true
+	}
+	var got []string
+	for _, field := range c.order {
+		if considered[field] {
+			got = append(got, field)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got resolution order %q, want %q", got, want)
+	}
+}
+
+// checkRecorded fails the test if no resolver calls were recorded. c.mu must be held.
+func (c *resolverCalls) checkRecorded(t testing.TB) {
+	if len(c.counts) == 0 {
+		t.Fatal("no resolver calls were recorded; build the schema with graphql.Tracer(gqltesting.Instrument(...))")
+	}
+}
+
+// Trace is the timing of an operation executed by RunTestWithTrace, modeled after Apollo tracing.
+type Trace struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	Resolvers []*ResolverTrace
+}
+
+// ResolverTrace is the timing of a single resolver invocation.
+type ResolverTrace struct {
+	ParentType  string
+	FieldName   string
+	StartOffset time.Duration // relative to Trace.StartTime
+	Duration    time.Duration
+	Err         *errors.QueryError
+}
+
+type traceRecorderKey struct{}
+
+type traceRecorder struct {
+	now   func() time.Time
+	mu    sync.Mutex
+	trace *Trace
+}
+
+func withTraceRecorder(ctx context.Context, tr *Trace, now func() time.Time) (context.Context, *traceRecorder) {
+	if now == nil {
+		now = time.Now
+	}
+	rec := &traceRecorder{now: now, trace: tr}
+	tr.StartTime = now()
+	return context.WithValue(ctx, traceRecorderKey{}, rec), rec
+}
+
+func (r *traceRecorder) add(res *ResolverTrace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trace.Resolvers = append(r.trace.Resolvers, res)
+}
+
+// finish completes the trace, sorting resolvers by start offset.
+func (r *traceRecorder) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trace.EndTime = r.now()
+	r.trace.Duration = r.trace.EndTime.Sub(r.trace.StartTime)
+	sort.SliceStable(r.trace.Resolvers, func(i, j int) bool {
+		return r.trace.Resolvers[i].StartOffset < r.trace.Resolvers[j].StartOffset
+	})
+}
+
+// check fails the test for every invocation that took longer than allowed by max, keyed by
+// "Type.field".
+func (r *traceRecorder) check(t testing.TB, max map[string]time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.trace.Resolvers) == 0 {
+		t.Fatal("no resolvers were traced; build the schema with graphql.Tracer(gqltesting.Instrument(...))")
+	}
+
+	for _, res := range r.trace.Resolvers {
+		field := res.ParentType + "." + res.FieldName
+		if limit, ok := max[field]; ok && res.Duration > limit {
+			t.Errorf("%s took %s, want at most %s", field, res.Duration, limit)
+		}
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
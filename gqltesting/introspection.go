@@ -0,0 +1,219 @@
+package gqltesting
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// RunIntrospectionSnapshot executes the standard introspection query against schema and compares
+// the result with the golden file at goldenPath, which is rewritten when running go test -update
+// or with UpdateEnv set. Committing the golden file makes every change to the schema show up as a
+// reviewable diff.
+func RunIntrospectionSnapshot(t *testing.T, schema *graphql.Schema, goldenPath string) {
+	t.Helper()
+	data, err := schema.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkData(t, &comparison{}, goldenResult(t, goldenPath, data), data)
+}
+
+// introspectionQueries are the queries AssertIntrospectionDisabled expects to be answered without
+// any introspection data.
+var introspectionQueries = []string{
+	`{ __schema { types { name } } }`,
+	`{ __type(name: "Query") { name } }`,
+}
+
+// AssertIntrospectionDisabled checks that introspection is disabled for schema, which must be
+// built with a resolver, typically with graphql.DisableIntrospection. The executor then answers
+// introspection queries such as { __schema { types { name } } } without errors but also without
+// the introspection fields, as if they had not been selected, so the data must be an empty object.
+func AssertIntrospectionDisabled(t *testing.T, schema *graphql.Schema) {
+	t.Helper()
+	for _, query := range introspectionQueries {
+		result := schema.Exec(context.Background(), query, "", nil)
+		if len(result.Errors) > 0 {
+			t.Errorf("query %s: got errors %s, want introspection fields to be omitted", query, errorMessages(result.Errors))
+			continue
+		}
+		if data, err := FormatJSON(result.Data); err != nil || string(data) != "{}" {
+			t.Errorf("query %s: got data %s, want {} since introspection is disabled", query, result.Data)
+		}
+	}
+}
+
+// AssertSchemaEqual checks that schemas a and b are structurally equal, comparing their
+// introspection regardless of the order in which types, fields, arguments, enum values and
+// directives are declared. It reports the first type or field that differs.
+func AssertSchemaEqual(t *testing.T, a, b *graphql.Schema) {
+	t.Helper()
+	sa := normalizedSchema(t, a)
+	sb := normalizedSchema(t, b)
+	if reflect.DeepEqual(sa, sb) {
+		return
+	}
+
+	for _, key := range []string{"queryType", "mutationType", "subscriptionType"} {
+		if !reflect.DeepEqual(sa[key], sb[key]) {
+			t.Fatalf("schemas differ in %s:\na: %s\nb: %s", key, jsonString(sa[key]), jsonString(sb[key]))
+		}
+	}
+	if diff := firstNamedDifference(sa["types"], sb["types"], "type", nil); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := firstNamedDifference(sa["directives"], sb["directives"], "directive", nil); diff != "" {
+		t.Fatal(diff)
+	}
+	t.Fatal("schemas differ")
+}
+
+// schemaListPaths are the lists of the introspection whose order is not significant.
+var schemaListPaths = []string{
+	"types",
+	"types[].fields",
+	"types[].fields[].args",
+	"types[].inputFields",
+	"types[].enumValues",
+	"types[].interfaces",
+	"types[].possibleTypes",
+	"directives",
+	"directives[].args",
+}
+
+// memberLists are the lists of named members of a type whose differences are reported precisely.
+var memberLists = []string{"fields", "inputFields", "enumValues"}
+
+func normalizedSchema(t testing.TB, schema *graphql.Schema) map[string]interface{} {
+	data, err := schema.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := decodeJSON(data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make([]string, len(schemaListPaths))
+	for i, p := range schemaListPaths {
+		paths[i] = "__schema." + p
+	}
+	v = sortByName(paths...)(v)
+	s, _ := v.(map[string]interface{})["__schema"].(map[string]interface{})
+	return s
+}
+
+// firstNamedDifference describes the first element, by name, of the lists of objects a and b that
+// is missing from either list or differs between them. For differing types, the members are
+// compared in turn, so that the difference is reported for a field rather than the whole type.
+func firstNamedDifference(a, b interface{}, kind string, parent []string) string {
+	ia, ib := indexByName(a), indexByName(b)
+	names := make([]string, 0, len(ia)+len(ib))
+	for name := range ia {
+		names = append(names, name)
+	}
+	for name := range ib {
+		if _, ok := ia[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		qualified := name
+		if len(parent) > 0 {
+			qualified = parent[0] + "." + name
+		}
+		ea, oka := ia[name]
+		eb, okb := ib[name]
+		switch {
+		case !okb:
+			return fmt.Sprintf("%s %s is only in a", kind, qualified)
+		case !oka:
+			return fmt.Sprintf("%s %s is only in b", kind, qualified)
+		case reflect.DeepEqual(ea, eb):
+			continue
+		}
+		if kind == "type" {
+			for _, list := range memberLists {
+				if diff := firstNamedDifference(ea[list], eb[list], "field", []string{name}); diff != "" {
+					return diff
+				}
+			}
+		}
+		return fmt.Sprintf("%s %s differs:\na: %s\nb: %s", kind, qualified, jsonString(ea), jsonString(eb))
+	}
+	return ""
+}
+
+func indexByName(v interface{}) map[string]map[string]interface{} {
+	index := make(map[string]map[string]interface{})
+	list, _ := v.([]interface{})
+	for _, elem := range list {
+		if obj, ok := elem.(map[string]interface{}); ok {
+			index[objectName(obj)] = obj
+		}
+	}
+	return index
+}
+
+func jsonString(v interface{}) string {
+	data, err := encodeJSON(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// AssertFieldDeprecated checks, by introspecting schema, that the field fieldName of the type
+// typeName is deprecated with the reason wantReason. It guards deprecations against being removed
+// or reworded by accident.
+func AssertFieldDeprecated(t *testing.T, schema *graphql.Schema, typeName, fieldName, wantReason string) {
+	t.Helper()
+	f := lookupField(t, schema, typeName, fieldName)
+	if !f.IsDeprecated() {
+		t.Fatalf("%s.%s is not deprecated, want deprecation reason %q", typeName, fieldName, wantReason)
+	}
+	if got := f.DeprecationReason(); got == nil || *got != wantReason {
+		var reason interface{}
+		if got != nil {
+			reason = *got
+		}
+		t.Errorf("%s.%s has deprecation reason %q, want %q", typeName, fieldName, reason, wantReason)
+	}
+}
+
+// lookupType returns the introspection of the named type in schema, or nil if there is none.
+func lookupType(schema *graphql.Schema, typeName string) *introspection.Type {
+	for _, typ := range schema.Inspect().Types() {
+		if name := typ.Name(); name != nil && *name == typeName {
+			return typ
+		}
+	}
+	return nil
+}
+
+// lookupField returns the introspection of the field typeName.fieldName, including deprecated
+// fields, failing the test if there is no such field.
+func lookupField(t testing.TB, schema *graphql.Schema, typeName, fieldName string) *introspection.Field {
+	typ := lookupType(schema, typeName)
+	if typ == nil {
+		t.Fatalf("schema has no type %q", typeName)
+	}
+	fields := typ.Fields(&struct{ IncludeDeprecated bool }{true})
+	if fields == nil {
+		t.Fatalf("type %q has no fields", typeName)
+	}
+	for _, f := range *fields {
+		if f.Name() == fieldName {
+			return f
+		}
+	}
+	t.Fatalf("type %q has no field %q", typeName, fieldName)
+	return nil
+}
@@ -0,0 +1,333 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// checkJSONSchema fails the test for every violation of the JSON Schema schema by data.
+func checkJSONSchema(t testing.TB, schema string, data []byte) {
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		t.Fatalf("ResponseJSONSchema: invalid JSON: %s", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("got: %s", invalidJSON(err, data))
+	}
+
+	var violations []string
+	if err := validateJSONSchema(s, v, "data", &violations); err != nil {
+		t.Fatalf("ResponseJSONSchema: %s", err)
+	}
+	for _, v := range violations {
+		t.Error(v)
+	}
+}
+
+// validateJSONSchema appends to violations a description of every way v at path violates the
+// JSON Schema s. It supports the commonly used subset of the keywords of draft 7 listed on
+// Test.ResponseJSONSchema, and returns an error for schemas it cannot interpret.
+func validateJSONSchema(s map[string]interface{}, v interface{}, path string, violations *[]string) error {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	for _, keyword := range sortedMembers(s) {
+		arg := s[keyword]
+		switch keyword {
+		case "$schema", "$id", "title", "description", "examples", "$comment", "default":
+		case "type":
+			types, err := schemaStrings(keyword, arg)
+			if err != nil {
+				return err
+			}
+			if !jsonTypeMatches(v, types) {
+				fail("got %s, want %s", jsonType(v), strings.Join(types, " or "))
+				// The other keywords would only report the same mismatch again.
+				return nil
+			}
+		case "enum":
+			values, ok := arg.([]interface{})
+			if !ok {
+				return fmt.Errorf("enum is not an array")
+			}
+			if !containsJSON(values, v) {
+				fail("got %s, want one of %s", jsonString(v), jsonString(values))
+			}
+		case "const":
+			if !reflect.DeepEqual(arg, v) {
+				fail("got %s, want %s", jsonString(v), jsonString(arg))
+			}
+		case "properties", "required", "additionalProperties", "minProperties", "maxProperties":
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := validateObject(s, keyword, arg, obj, path, violations, fail); err != nil {
+				return err
+			}
+		case "items", "minItems", "maxItems", "uniqueItems":
+			list, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+			if err := validateList(keyword, arg, list, path, violations, fail); err != nil {
+				return err
+			}
+		case "minLength", "maxLength", "pattern":
+			str, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if keyword == "pattern" {
+				pattern, ok := arg.(string)
+				if !ok {
+					return fmt.Errorf("pattern is not a string")
+				}
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("pattern: %s", err)
+				}
+				if !re.MatchString(str) {
+					fail("got %q, want a string matching %q", str, pattern)
+				}
+				continue
+			}
+			n, err := schemaInt(keyword, arg)
+			if err != nil {
+				return err
+			}
+			if l := utf8.RuneCountInString(str); keyword == "minLength" && l < n || keyword == "maxLength" && l > n {
+				fail("got a string of length %d, want %s %d", l, keyword, n)
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			num, ok := v.(float64)
+			if !ok {
+				continue
+			}
+			limit, ok := arg.(float64)
+			if !ok {
+				return fmt.Errorf("%s is not a number", keyword)
+			}
+			var violated bool
+			switch keyword {
+			case "minimum":
+				violated = num < limit
+			case "maximum":
+				violated = num > limit
+			case "exclusiveMinimum":
+				violated = num <= limit
+			case "exclusiveMaximum":
+				violated = num >= limit
+			case "multipleOf":
+				violated = limit <= 0 || math.Mod(num, limit) != 0
+			}
+			if violated {
+				fail("got %v, want %s %v", num, keyword, limit)
+			}
+		case "anyOf", "oneOf":
+			schemas, ok := arg.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s is not an array", keyword)
+			}
+			matches := 0
+			for _, sub := range schemas {
+				subSchema, ok := sub.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s holds a schema that is not an object", keyword)
+				}
+				var subViolations []string
+				if err := validateJSONSchema(subSchema, v, path, &subViolations); err != nil {
+					return err
+				}
+				if len(subViolations) == 0 {
+					matches++
+				}
+			}
+			if matches == 0 || keyword == "oneOf" && matches > 1 {
+				fail("got %s, which matches %d of the %s schemas", jsonString(v), matches, keyword)
+			}
+		default:
+			return fmt.Errorf("unsupported keyword %q at %s", keyword, path)
+		}
+	}
+	return nil
+}
+
+func validateObject(s map[string]interface{}, keyword string, arg interface{}, obj map[string]interface{}, path string, violations *[]string, fail func(string, ...interface{})) error {
+	switch keyword {
+	case "properties":
+		props, ok := arg.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("properties is not an object")
+		}
+		for _, name := range sortedMembers(props) {
+			sub, ok := props[name].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("property %q is not a schema", name)
+			}
+			if value, ok := obj[name]; ok {
+				if err := validateJSONSchema(sub, value, path+"."+name, violations); err != nil {
+					return err
+				}
+			}
+		}
+	case "required":
+		names, err := schemaStrings(keyword, arg)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if _, ok := obj[name]; !ok {
+				fail("missing required field %q", name)
+			}
+		}
+	case "additionalProperties":
+		props, _ := s["properties"].(map[string]interface{})
+		for _, name := range sortedMembers(obj) {
+			if _, ok := props[name]; ok {
+				continue
+			}
+			switch arg := arg.(type) {
+			case bool:
+				if !arg {
+					fail("unexpected field %q", name)
+				}
+			case map[string]interface{}:
+				if err := validateJSONSchema(arg, obj[name], path+"."+name, violations); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("additionalProperties is neither a boolean nor a schema")
+			}
+		}
+	case "minProperties", "maxProperties":
+		n, err := schemaInt(keyword, arg)
+		if err != nil {
+			return err
+		}
+		if keyword == "minProperties" && len(obj) < n || keyword == "maxProperties" && len(obj) > n {
+			fail("got %d fields, want %s %d", len(obj), keyword, n)
+		}
+	}
+	return nil
+}
+
+func validateList(keyword string, arg interface{}, list []interface{}, path string, violations *[]string, fail func(string, ...interface{})) error {
+	switch keyword {
+	case "items":
+		sub, ok := arg.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("items is not a schema; tuple validation is not supported")
+		}
+		for i, elem := range list {
+			if err := validateJSONSchema(sub, elem, fmt.Sprintf("%s[%d]", path, i), violations); err != nil {
+				return err
+			}
+		}
+	case "minItems", "maxItems":
+		n, err := schemaInt(keyword, arg)
+		if err != nil {
+			return err
+		}
+		if keyword == "minItems" && len(list) < n || keyword == "maxItems" && len(list) > n {
+			fail("got %d items, want %s %d", len(list), keyword, n)
+		}
+	case "uniqueItems":
+		if unique, _ := arg.(bool); !unique {
+			return nil
+		}
+		for i := range list {
+			if containsJSON(list[:i], list[i]) {
+				fail("item %d is a duplicate", i)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonType returns the JSON Schema type of the decoded JSON value v.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func jsonTypeMatches(v interface{}, types []string) bool {
+	got := jsonType(v)
+	for _, typ := range types {
+		if typ == got || typ == "number" && got == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func containsJSON(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if reflect.DeepEqual(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaStrings returns the argument of keyword, either a string or an array of strings.
+func schemaStrings(keyword string, arg interface{}) ([]string, error) {
+	switch arg := arg.(type) {
+	case string:
+		return []string{arg}, nil
+	case []interface{}:
+		strs := make([]string, len(arg))
+		for i, v := range arg {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s holds %v, which is not a string", keyword, v)
+			}
+			strs[i] = s
+		}
+		sort.Strings(strs)
+		return strs, nil
+	default:
+		return nil, fmt.Errorf("%s is neither a string nor an array of strings", keyword)
+	}
+}
+
+func schemaInt(keyword string, arg interface{}) (int, error) {
+	n, ok := arg.(float64)
+	if !ok || n != math.Trunc(n) || n < 0 {
+		return 0, fmt.Errorf("%s is not a non-negative integer", keyword)
+	}
+	return int(n), nil
+}
+
+func sortedMembers(obj map[string]interface{}) []string {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
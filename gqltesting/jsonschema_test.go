@@ -0,0 +1,87 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	const userSchema = `{
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "string", "pattern": "^u[0-9]+$"},
+			"name": {"type": "string", "minLength": 1},
+			"age": {"type": ["integer", "null"], "minimum": 0},
+			"role": {"enum": ["ADMIN", "MEMBER"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2, "uniqueItems": true}
+		}
+	}`
+
+	tests := []struct {
+		name       string
+		data       string
+		violations []string
+	}{
+		{
+			name: "valid",
+			data: `{"id": "u1", "name": "Alice", "age": null, "role": "ADMIN", "tags": ["a", "b"]}`,
+		},
+		{
+			name: "wrong types",
+			data: `{"id": 1, "name": "Alice", "age": 1.5}`,
+			violations: []string{
+				"data.age: got number, want integer or null",
+				"data.id: got integer, want string",
+			},
+		},
+		{
+			name: "constraints",
+			data: `{"id": "x1", "name": "", "age": -1, "role": "GUEST", "tags": ["a", "a", "b"], "extra": true}`,
+			violations: []string{
+				`data: unexpected field "extra"`,
+				"data.age: got -1, want minimum 0",
+				`data.id: got "x1", want a string matching "^u[0-9]+$"`,
+				"data.name: got a string of length 0, want minLength 1",
+				`data.role: got "GUEST", want one of ["ADMIN","MEMBER"]`,
+				"data.tags: got 3 items, want maxItems 2",
+				"data.tags: item 1 is a duplicate",
+			},
+		},
+		{
+			name:       "missing field",
+			data:       `{"id": "u1"}`,
+			violations: []string{`data: missing required field "name"`},
+		},
+	}
+
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(userSchema), &s); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.data), &v); err != nil {
+				t.Fatal(err)
+			}
+			var violations []string
+			if err := validateJSONSchema(s, v, "data", &violations); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(violations, tt.violations) {
+				t.Errorf("got violations %q, want %q", violations, tt.violations)
+			}
+		})
+	}
+}
+
+func TestValidateJSONSchema_unsupported(t *testing.T) {
+	s := map[string]interface{}{"$ref": "#/definitions/user"}
+	if err := validateJSONSchema(s, nil, "data", new([]string)); err == nil {
+		t.Error("expected an error for an unsupported keyword")
+	}
+}
@@ -0,0 +1,86 @@
+package gqltesting
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakGracePeriod is how long WithLeakCheck waits for the goroutines started by a test to exit.
+const leakGracePeriod = time.Second
+
+// ignoredGoroutines are the functions whose goroutines are not reported as leaked: the goroutines
+// of the testing package, which runs other tests concurrently, and long-lived goroutines the
+// runtime and the standard library start on demand.
+var ignoredGoroutines = []string{
+	"testing.tRunner",
+	"testing.(*T).Run",
+	"testing.(*T).Parallel",
+	"testing.runTests",
+	"testing.(*M).",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+	"runtime/trace.Start",
+}
+
+// goroutines returns the stacks of the running goroutines that are not ignored, keyed by the
+// "goroutine 7" prefix of their header, whose ID is unique for the lifetime of the process.
+func goroutines() map[string]string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	stacks := make(map[string]string)
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		if ignoredGoroutine(stack) {
+			continue
+		}
+		id := stack
+		if i := strings.IndexByte(stack, '['); i >= 0 {
+			id = stack[:i]
+		}
+		stacks[strings.TrimSpace(id)] = stack
+	}
+	return stacks
+}
+
+func ignoredGoroutine(stack string) bool {
+	for _, fn := range ignoredGoroutines {
+		if strings.Contains(stack, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLeaks fails the test, reporting their stacks, if goroutines missing from before are still
+// running once deadline fires.
+func checkLeaks(t testing.TB, before map[string]string, deadline <-chan time.Time) {
+	for {
+		var leaked []string
+		for id, stack := range goroutines() {
+			if _, ok := before[id]; !ok {
+				leaked = append(leaked, stack)
+			}
+		}
+		if len(leaked) == 0 {
+			return
+		}
+		select {
+		case <-deadline:
+			sort.Strings(leaked)
+			t.Errorf("%d goroutine(s) leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package gqltesting
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// AssertErrorAt asserts that one of the errors of result was reported at the given 1-based line
+// and column of query, as validation errors are. Errors without locations, such as those
+// returned by resolvers, are ignored. On failure, every error is listed with its locations, along
+// with the line of query the assertion points at.
+func AssertErrorAt(t *testing.T, query string, result *graphql.Response, line, column int) {
+	t.Helper()
+	var b strings.Builder
+	for _, err := range result.Errors {
+		for _, loc := range err.Locations {
+			if loc.Line == line && loc.Column == column {
+				return
+			}
+		}
+		if len(err.Locations) == 0 {
+			fmt.Fprintf(&b, "\n\t(no location) %q", err.Message)
+			continue
+		}
+		locs := make([]string, len(err.Locations))
+		for i, loc := range err.Locations {
+			locs[i] = fmt.Sprintf("%d:%d", loc.Line, loc.Column)
+		}
+		fmt.Fprintf(&b, "\n\t%s %q", strings.Join(locs, ", "), err.Message)
+	}
+
+	if len(result.Errors) == 0 {
+		b.WriteString(" none")
+	}
+	t.Errorf("no error at %d:%d of the query\n%s\ngot errors:%s", line, column, sourceLine(query, line, column), b.String())
+}
+
+// sourceLine returns the given line of src with a caret under the given column, both 1-based.
+func sourceLine(src string, line, column int) string {
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return fmt.Sprintf("(the query has %d lines)", len(lines))
+	}
+	text := strings.TrimRight(lines[line-1], "\r")
+
+	// Keep tabs in the padding so that the caret lines up with the text.
+	var pad strings.Builder
+	for i, r := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+	return fmt.Sprintf("%4d | %s\n     | %s^", line, text, pad.String())
+}
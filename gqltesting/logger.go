@@ -0,0 +1,60 @@
+package gqltesting
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// A ResolverLogger is the logger resolvers retrieve from their context with
+// ResolverLoggerFromContext, to print debugging output that WithResolverLogging attributes to the
+// test executing them. *testing.T implements it.
+type ResolverLogger interface {
+	Logf(format string, args ...interface{})
+}
+
+type resolverLoggerKey struct{}
+
+// ResolverLoggerFromContext returns the ResolverLogger installed in ctx by WithResolverLogging.
+// Outside of such tests, including in production, it returns a logger discarding its output, so
+// resolvers can log unconditionally:
+//
+//	gqltesting.ResolverLoggerFromContext(ctx).Logf("loading user %s", id)
+func ResolverLoggerFromContext(ctx context.Context) ResolverLogger {
+	if logger, ok := ctx.Value(resolverLoggerKey{}).(ResolverLogger); ok {
+		return logger
+	}
+	return discardLogger{}
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Logf(format string, args ...interface{}) {}
+
+// testLogger forwards to the log of a test until the test returns. Abandoned resolvers, such as
+// blocking ones, may log later, which testing.T does not allow.
+type testLogger struct {
+	mu   sync.Mutex
+	t    testing.TB
+	done bool
+}
+
+func withTestLogger(ctx context.Context, t testing.TB) (context.Context, *testLogger) {
+	logger := &testLogger{t: t}
+	return context.WithValue(ctx, resolverLoggerKey{}, ResolverLogger(logger)), logger
+}
+
+func (l *testLogger) Logf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.done {
+		l.t.Logf(format, args...)
+	}
+}
+
+// close drops the output logged from now on.
+func (l *testLogger) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done = true
+}
@@ -0,0 +1,91 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+)
+
+// A FieldMatcher asserts something about the value of a field in the result, returning a
+// descriptive error if the value does not match. Values are decoded as by encoding/json.
+type FieldMatcher func(value interface{}) error
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID matches strings formatted as a UUID.
+func IsUUID(value interface{}) error {
+	s, ok := value.(string)
+	if !ok || !uuidPattern.MatchString(s) {
+		return fmt.Errorf("got %v, want a UUID", value)
+	}
+	return nil
+}
+
+// IsRFC3339Time matches strings holding a time formatted as RFC 3339.
+func IsRFC3339Time(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("got %v, want an RFC 3339 time", value)
+	}
+	if _, err := time.Parse(time.RFC3339, s); err != nil {
+		return fmt.Errorf("got %q, want an RFC 3339 time: %s", s, err)
+	}
+	return nil
+}
+
+// TimeEqual matches RFC 3339 times denoting the same instant as want, regardless of their time
+// zone offset or fractional-second precision, so that for example "2020-01-01T00:00:00Z" matches
+// "2020-01-01T00:00:00.000+00:00".
+func TimeEqual(want string) FieldMatcher {
+	return func(value interface{}) error {
+		w, err := time.Parse(time.RFC3339Nano, want)
+		if err != nil {
+			return fmt.Errorf("invalid expected time %q: %s", want, err)
+		}
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("got %v, want a time equal to %s", value, want)
+		}
+		got, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("got %q, want an RFC 3339 time: %s", s, err)
+		}
+		if !got.Equal(w) {
+			return fmt.Errorf("got %s, want a time equal to %s", s, want)
+		}
+		return nil
+	}
+}
+
+// checkFieldMatchers runs each matcher against every value its path addresses in data.
+func checkFieldMatchers(t testing.TB, matchers map[string]FieldMatcher, data []byte) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("got: %s", invalidJSON(err, data))
+	}
+
+	for _, p := range sortedMatcherPaths(matchers) {
+		values := parsePath(p).lookup(v)
+		if len(values) == 0 {
+			t.Errorf("%s: no such field in result", p)
+			continue
+		}
+		for _, value := range values {
+			if err := matchers[p](value); err != nil {
+				t.Errorf("%s: %s", p, err)
+			}
+		}
+	}
+}
+
+func sortedMatcherPaths(matchers map[string]FieldMatcher) []string {
+	paths := make([]string, 0, len(matchers))
+	for p := range matchers {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
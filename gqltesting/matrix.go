@@ -0,0 +1,30 @@
+package gqltesting
+
+import (
+	"sort"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// RunTestMatrix runs test once against each of the given schemas, as a subtest named after the
+// schema. test.Schema is ignored. The expected result for a schema may be overridden through
+// test.ExpectedResultBySchema, making divergent behavior between the variants explicit.
+func RunTestMatrix(t *testing.T, schemas map[string]*graphql.Schema, test *Test, opts ...Option) {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		variant := *test
+		variant.Schema = schemas[name]
+		if expected, ok := test.ExpectedResultBySchema[name]; ok {
+			variant.ExpectedResult = expected
+		}
+		t.Run(subtestName(name, "", i), func(t *testing.T) {
+			RunTest(t, &variant, opts...)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A normalizer rewrites a decoded JSON tree before it is compared. Normalizers are applied to the
+// expected and actual results alike so that both sides are compared on equal terms.
+type normalizer func(interface{}) interface{}
+
+// unorderedLists returns a normalizer sorting the lists at the given paths, or every list if no
+// paths are given.
+func unorderedLists(paths []string) normalizer {
+	if len(paths) == 0 {
+		return sortAllLists
+	}
+	return func(v interface{}) interface{} {
+		for _, p := range paths {
+			v = parsePath(p).transform(v, sortList)
+		}
+		return v
+	}
+}
+
+func sortAllLists(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = sortAllLists(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = sortAllLists(child)
+		}
+		return sortList(v)
+	}
+	return v
+}
+
+// sortList orders the elements of a list by their canonical JSON encoding.
+func sortList(v interface{}) interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+
+	keys := make(map[int]string, len(list))
+	for i, elem := range list {
+		b, _ := json.Marshal(elem)
+		keys[i] = string(b)
+	}
+	idx := make([]int, len(list))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return keys[idx[i]] < keys[idx[j]]
+	})
+
+	sorted := make([]interface{}, len(list))
+	for i, j := range idx {
+		sorted[i] = list[j]
+	}
+	return sorted
+}
+
+// sortByName returns a normalizer sorting the lists of objects at the given paths by the value of
+// their "name" field.
+func sortByName(paths ...string) normalizer {
+	return func(v interface{}) interface{} {
+		for _, p := range paths {
+			v = parsePath(p).transform(v, func(v interface{}) interface{} {
+				list, ok := v.([]interface{})
+				if !ok {
+					return v
+				}
+				sort.SliceStable(list, func(i, j int) bool {
+					return objectName(list[i]) < objectName(list[j])
+				})
+				return list
+			})
+		}
+		return v
+	}
+}
+
+// objectName returns the "name" field of v if it is an object with a string name.
+func objectName(v interface{}) string {
+	obj, _ := v.(map[string]interface{})
+	name, _ := obj["name"].(string)
+	return name
+}
+
+// ignoreFields returns a normalizer deleting the fields at the given paths.
+func ignoreFields(paths []string) normalizer {
+	return func(v interface{}) interface{} {
+		for _, p := range paths {
+			v = parsePath(p).remove(v)
+		}
+		return v
+	}
+}
+
+// foldKeys rewrites every object key to lower case without underscores, so that "first_name",
+// "firstName" and "FirstName" are all compared as "firstname".
+func foldKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		folded := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			folded[strings.ToLower(strings.Replace(k, "_", "", -1))] = foldKeys(child)
+		}
+		return folded
+	case []interface{}:
+		for i, child := range v {
+			v[i] = foldKeys(child)
+		}
+	}
+	return v
+}
+
+// numericIDs returns a normalizer rewriting the numbers at the given paths, or every number if no
+// paths are given, to strings, so that an ID serialized as 123 compares equal to "123".
+func numericIDs(paths []string) normalizer {
+	if len(paths) == 0 {
+		return allNumbersToStrings
+	}
+	return func(v interface{}) interface{} {
+		for _, p := range paths {
+			v = parsePath(p).transform(v, func(v interface{}) interface{} {
+				if list, ok := v.([]interface{}); ok {
+					for i, elem := range list {
+						list[i] = numberToString(elem)
+					}
+					return list
+				}
+				return numberToString(v)
+			})
+		}
+		return v
+	}
+}
+
+func allNumbersToStrings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = allNumbersToStrings(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = allNumbersToStrings(child)
+		}
+	}
+	return numberToString(v)
+}
+
+// numberToString returns the decimal representation of v if it is a number, and v otherwise.
+func numberToString(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	}
+	return v
+}
+
+// jsonStrings returns a normalizer decoding the strings at the given paths that hold JSON
+// documents, so that they are compared structurally.
+func jsonStrings(paths []string) normalizer {
+	return func(v interface{}) interface{} {
+		for _, p := range paths {
+			v = parsePath(p).transform(v, decodeJSONString)
+		}
+		return v
+	}
+}
+
+// decodeJSONString returns the value encoded by v if it is a string holding a JSON document, and v
+// otherwise.
+func decodeJSONString(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+		return v
+	}
+	return decoded
+}
@@ -0,0 +1,61 @@
+package gqltesting
+
+import (
+	"sort"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// MissingOperationNameError returns the error reported for a document with several operations
+// when no operation name is given.
+func MissingOperationNameError() *errors.QueryError {
+	return errors.Errorf("more than one operation in query document and no operation name given")
+}
+
+// UnknownOperationError returns the error reported when the document has no operation with the
+// given name.
+func UnknownOperationError(name string) *errors.QueryError {
+	return errors.Errorf("no operation with name %q", name)
+}
+
+// RunOperationNameTests executes a document containing several named operations once per entry
+// of expected, selecting the operation by its key and comparing the data against its value. It
+// also asserts that omitting the operation name and requesting an operation missing from the
+// document fail with MissingOperationNameError and UnknownOperationError respectively.
+func RunOperationNameTests(t *testing.T, schema *graphql.Schema, document string, expected map[string]string, opts ...Option) {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t.Run("no operation name", func(t *testing.T) {
+		RunTest(t, &Test{
+			Schema:         schema,
+			Query:          document,
+			ExpectedErrors: []*errors.QueryError{MissingOperationNameError()},
+		}, opts...)
+	})
+	t.Run("unknown operation name", func(t *testing.T) {
+		const name = "gqltestingUnknownOperation"
+		RunTest(t, &Test{
+			Schema:         schema,
+			Query:          document,
+			OperationName:  name,
+			ExpectedErrors: []*errors.QueryError{UnknownOperationError(name)},
+		}, opts...)
+	})
+	for _, name := range names {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			RunTest(t, &Test{
+				Schema:         schema,
+				Query:          document,
+				OperationName:  name,
+				ExpectedResult: expected[name],
+			}, opts...)
+		})
+	}
+}
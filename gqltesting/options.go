@@ -0,0 +1,359 @@
+package gqltesting
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// Option configures how RunTest and RunTests execute test cases.
+type Option func(*runConfig)
+
+type runConfig struct {
+	ctx           context.Context
+	contextValues [][2]interface{}
+	timeout       time.Duration
+	parallel      bool
+	strict        bool
+	strictErrors  bool
+	orderedErrors bool
+	ignoreErrors  bool
+	exactShape    bool
+	concurrency   int
+	httpRoundTrip bool
+	prepared      bool
+	exec          execFunc
+
+	retries   int
+	backoff   time.Duration
+	transient TransientFunc
+	cassette  string
+
+	normalizers    []normalizer
+	useNumber      bool
+	floatTolerance float64
+	placeholder    string
+	base64Paths    []string
+
+	trace *Trace
+	now   func() time.Time
+
+	dump       bool
+	dumpWriter io.Writer
+	jsonOutput bool
+	noColor    bool
+	logging    bool
+	quiet      bool
+	leakCheck  bool
+}
+
+func newRunConfig(opts []Option) *runConfig {
+	cfg := &runConfig{
+		placeholder: DefaultPlaceholder,
+		jsonOutput:  os.Getenv("GQLTESTING_OUTPUT") == "json",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// execFunc returns how tests are executed, in process by default.
+func (cfg *runConfig) execFunc() execFunc {
+	exec := cfg.exec
+	switch {
+	case exec == nil && cfg.prepared:
+		exec = preparedExec()
+	case exec == nil:
+		exec = schemaExec
+	}
+	if cfg.retries > 0 && cfg.transient != nil {
+		exec = retrying(exec, cfg.retries, cfg.backoff, cfg.transient)
+	}
+	if cfg.cassette != "" {
+		exec = cassetteExec(exec, cfg.cassette)
+	}
+	return exec
+}
+
+// WithContext executes the test with the given context. It takes precedence over Test.Context,
+// which is ignored when this option is supplied.
+func WithContext(ctx context.Context) Option {
+	return func(cfg *runConfig) {
+		cfg.ctx = ctx
+	}
+}
+
+// WithContextValue adds a value to the test context, on top of the base context and any
+// Test.ContextValues, which it shadows for the same key. It is typically used to select the
+// authenticated principal or tenant a query runs as.
+func WithContextValue(key, value interface{}) Option {
+	return func(cfg *runConfig) {
+		cfg.contextValues = append(cfg.contextValues, [2]interface{}{key, value})
+	}
+}
+
+// WithTimeout wraps the test context, whether it comes from Test.Context or WithContext, in a
+// context.WithTimeout of the given duration. It replaces DefaultTimeout for the test.
+func WithTimeout(d time.Duration) Option {
+	return func(cfg *runConfig) {
+		cfg.timeout = d
+	}
+}
+
+// WithParallel marks each test as capable of running in parallel by calling t.Parallel.
+func WithParallel() Option {
+	return func(cfg *runConfig) {
+		cfg.parallel = true
+	}
+}
+
+// WithUnorderedLists ignores the order of the lists at the given paths (for example "data.users")
+// by sorting their elements before comparison. If no paths are given, all lists are unordered.
+func WithUnorderedLists(paths ...string) Option {
+	return func(cfg *runConfig) {
+		cfg.normalizers = append(cfg.normalizers, unorderedLists(paths))
+	}
+}
+
+// WithCaseInsensitiveKeys compares object keys ignoring case and underscores, so that a field
+// named "first_name" matches "firstName". It is meant for the transition period of a field naming
+// migration. The comparison is lossy: keys differing only in case or underscores collide, and only
+// one of their values is compared.
+func WithCaseInsensitiveKeys() Option {
+	return func(cfg *runConfig) {
+		cfg.normalizers = append(cfg.normalizers, foldKeys)
+	}
+}
+
+// WithNumericIDs compares the ID fields at the given paths, such as "data.users[].id", as strings,
+// so that 123 and "123" are equal whichever the backing source serializes. If no paths are given,
+// every number in the results is compared as a string, IDs or not.
+//
+// Numbers are converted to their shortest decimal representation, so they only match canonical
+// decimal strings: 123 matches "123" but not "0123" or "123.0", and 1e3 matches "1000". Numbers
+// above 2^53 lose precision when decoded as float64; WithJSONNumber preserves their digits, but
+// then converts numbers to their literal text, so that 1e3 only matches "1e3".
+func WithNumericIDs(paths ...string) Option {
+	return func(cfg *runConfig) {
+		cfg.normalizers = append(cfg.normalizers, numericIDs(paths))
+	}
+}
+
+// WithJSONStringFields compares the fields at the given paths, such as "data.settings.value", that
+// hold JSON documents encoded as strings, as is common for custom JSON scalars, by their decoded
+// structure rather than their text, so that the order of their object keys and their whitespace
+// do not matter. Strings that are not valid JSON are compared as is. Placeholders apply within the
+// decoded documents.
+func WithJSONStringFields(paths ...string) Option {
+	return func(cfg *runConfig) {
+		cfg.normalizers = append(cfg.normalizers, jsonStrings(paths))
+	}
+}
+
+// DefaultPlaceholder is the string that, as a leaf of Test.ExpectedResult or an expected golden
+// file, matches any value at the same position in the actual result, such as a generated ID or
+// timestamp. For example, {"user": {"id": "<ANY>", "name": "Alice"}} matches any user named Alice
+// that has an id, including a null one.
+const DefaultPlaceholder = "<ANY>"
+
+// WithPlaceholder replaces DefaultPlaceholder with token, so that results where "<ANY>" is itself
+// a meaningful value can still be compared exactly. An empty token disables placeholders. Lists
+// made unordered by WithUnorderedLists are sorted before placeholders are matched.
+func WithPlaceholder(token string) Option {
+	return func(cfg *runConfig) {
+		cfg.placeholder = token
+	}
+}
+
+// WithBase64Fields adds a hexdump of the decoded values of the base64 fields at the given paths,
+// such as "data.file.content" or "files[].content", to the diff of a failing test when they
+// differ, since a diff of the encoded strings is opaque. The fields are still compared as strings.
+func WithBase64Fields(paths ...string) Option {
+	return func(cfg *runConfig) {
+		cfg.base64Paths = append(cfg.base64Paths, paths...)
+	}
+}
+
+// WithExactShape fails the test if the set of object keys at any level of the result differs from
+// the expected result, independently of how the values compare. It catches fields that the fuzzy
+// options would hide, such as an extra field of an object matched by a placeholder. Lists are
+// compared element by element, and fields removed by Test.IgnoreFields or checked by
+// Test.FieldMatchers may be left out of the expected result.
+func WithExactShape() Option {
+	return func(cfg *runConfig) {
+		cfg.exactShape = true
+	}
+}
+
+// WithStrictValidation validates the query against the schema before executing it and fails the
+// test immediately, reporting the error locations, if the query is invalid. It is meant to catch
+// typos in queries, so it must not be used for tests that expect validation errors.
+func WithStrictValidation() Option {
+	return func(cfg *runConfig) {
+		cfg.strict = true
+	}
+}
+
+// WithFloatTolerance treats two numbers in the results as equal when their absolute or relative
+// difference is at most eps, to allow for platform-dependent rounding of computed floats.
+func WithFloatTolerance(eps float64) Option {
+	return func(cfg *runConfig) {
+		cfg.floatTolerance = eps
+	}
+}
+
+// WithClock makes resolver timings, as reported by RunTestWithTrace and checked against
+// Test.MaxFieldDuration, use the given clock instead of time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(cfg *runConfig) {
+		cfg.now = now
+	}
+}
+
+// withTrace records the timing of the test's resolvers into tr.
+func withTrace(tr *Trace) Option {
+	return func(cfg *runConfig) {
+		cfg.trace = tr
+	}
+}
+
+// WithStrictErrors compares Test.ExpectedErrors using reflect.DeepEqual only, so that numeric path
+// segments must also match in their concrete Go type.
+func WithStrictErrors() Option {
+	return func(cfg *runConfig) {
+		cfg.strictErrors = true
+	}
+}
+
+// WithOrderedErrors compares Test.ExpectedErrors with the returned errors in the exact order they
+// were returned, instead of sorting both by path first. It allows asserting that errors are
+// reported in document order.
+func WithOrderedErrors() Option {
+	return func(cfg *runConfig) {
+		cfg.orderedErrors = true
+	}
+}
+
+// WithIgnoreErrors allows any errors in the response of tests that do not set ExpectedErrors or
+// another error expectation, so that only their data is compared. It is meant for tests asserting
+// the shape of the data while errors are covered elsewhere. Use it sparingly: a new error, such as
+// a resolver failing for a field the expected data happens to allow to be null, goes unnoticed.
+// Unlike ExpectNoErrors, which asserts that there are no errors, it makes no assertion at all, and
+// error expectations set by a test still apply.
+func WithIgnoreErrors() Option {
+	return func(cfg *runConfig) {
+		cfg.ignoreErrors = true
+	}
+}
+
+// WithJSONNumber decodes numbers in the results as json.Number rather than float64, so that they
+// are compared by their literal text. This preserves the precision of integers beyond 2^53, such
+// as large IDs, which would otherwise be rounded before comparison.
+func WithJSONNumber() Option {
+	return func(cfg *runConfig) {
+		cfg.useNumber = true
+	}
+}
+
+// WithDumpOnFailure writes the complete response, including data, errors and extensions, to w
+// when the test fails. If w is nil, the response is written to the test log.
+func WithDumpOnFailure(w io.Writer) Option {
+	return func(cfg *runConfig) {
+		cfg.dump = true
+		cfg.dumpWriter = w
+	}
+}
+
+// WithConcurrency executes the query n times in parallel goroutines and fails the test, listing
+// the diverging iterations, unless every result matches the expected one. Combined with go test
+// -race, it flushes out data races in resolvers, such as unsynchronized caches or loaders.
+// Test.MaxResolverCalls applies to all iterations together.
+func WithConcurrency(n int) Option {
+	return func(cfg *runConfig) {
+		cfg.concurrency = n
+	}
+}
+
+// WithPreparedQuery parses the query once, with Schema.Prepare, and then only executes it, so that
+// RunBenchmark measures the resolvers rather than the parser, and WithConcurrency stresses them
+// with fewer allocations in between. The query is still validated on every execution. It has no
+// effect when the query is sent over HTTP, see WithHTTPRoundTrip.
+func WithPreparedQuery() Option {
+	return func(cfg *runConfig) {
+		cfg.prepared = true
+	}
+}
+
+// WithRetry executes the query again, up to n times and waiting backoff in between, while the
+// response contains an error for which transient returns true, such as a timeout of a real
+// dependency in an integration test. Only the last response is checked. A response that merely
+// has the wrong data or non-transient errors is never retried, so that regressions are not masked.
+// The retries share the deadline of the test, see WithTimeout.
+func WithRetry(n int, backoff time.Duration, transient TransientFunc) Option {
+	return func(cfg *runConfig) {
+		cfg.retries = n
+		cfg.backoff = backoff
+		cfg.transient = transient
+	}
+}
+
+// WithHTTPRoundTrip runs each test twice, as the subtests "Exec" and "HTTP": once in process with
+// Schema.Exec, and once by POSTing the query and variables as JSON to an httptest.Server serving
+// relay.Handler for the schema. The HTTP request is handled with the test context, so that context
+// values reach the resolvers. Since the Rule and ResolverError of errors are not serialized, they
+// are ignored in Test.ExpectedErrors when checking the HTTP response.
+func WithHTTPRoundTrip() Option {
+	return func(cfg *runConfig) {
+		cfg.httpRoundTrip = true
+	}
+}
+
+// WithJSONOutput additionally logs each failure as a single line of JSON describing the query,
+// expected and actual data, diff and errors, for tools annotating test results. Setting the
+// GQLTESTING_OUTPUT environment variable to "json" enables it for all tests.
+func WithJSONOutput() Option {
+	return func(cfg *runConfig) {
+		cfg.jsonOutput = true
+	}
+}
+
+// WithResolverLogging routes the output resolvers log with ResolverLoggerFromContext to the log of
+// the test, so that it is attributed to the right subtest and, as with t.Log, only shown when the
+// test fails or go test runs with -v. Output logged after the test returned, by resolvers it
+// abandoned, is dropped.
+func WithResolverLogging() Option {
+	return func(cfg *runConfig) {
+		cfg.logging = true
+	}
+}
+
+// WithLeakCheck fails the test if goroutines started while it ran, such as those spawned by
+// resolvers, are still running a grace period of one second after it completed, reporting their
+// stacks. The goroutines of the testing package and those the runtime starts on demand are not
+// considered. Since goroutines started by other tests running meanwhile are reported as well, the
+// test should not run in parallel with others.
+func WithLeakCheck() Option {
+	return func(cfg *runConfig) {
+		cfg.leakCheck = true
+	}
+}
+
+// WithQuiet holds back the diagnostics the test logs, such as the output of WithResolverLogging
+// and the golden files it updates, and only prints them if the test fails. Without -v, go test
+// already hides the output of passing tests; WithQuiet keeps verbose runs of many tests readable
+// as well.
+func WithQuiet() Option {
+	return func(cfg *runConfig) {
+		cfg.quiet = true
+	}
+}
+
+// WithoutColor prints diffs as plain text even when standard output is a terminal, for example to
+// keep the output deterministic when it is itself compared against a golden file.
+func WithoutColor() Option {
+	return func(cfg *runConfig) {
+		cfg.noColor = true
+	}
+}
@@ -0,0 +1,203 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// jsonFailure is the structured description of a failed test logged by WithJSONOutput.
+type jsonFailure struct {
+	Test          string               `json:"test"`
+	Query         string               `json:"query"`
+	OperationName string               `json:"operationName,omitempty"`
+	Expected      interface{}          `json:"expected"`
+	Got           interface{}          `json:"got"`
+	Diff          string               `json:"diff,omitempty"`
+	Errors        []*errors.QueryError `json:"errors,omitempty"`
+}
+
+// logJSONFailure logs a failed test as a single line of JSON.
+func logJSONFailure(t testing.TB, test *Test, cfg *runConfig, expected []byte, result *graphql.Response) {
+	if !t.Failed() {
+		return
+	}
+
+	f := jsonFailure{
+		Test:          t.Name(),
+		Query:         test.Query,
+		OperationName: test.OperationName,
+		Expected:      jsonValue(expected),
+		Got:           jsonValue(result.Data),
+		Errors:        result.Errors,
+	}
+	if len(expected) > 0 && len(result.Data) > 0 {
+		c := testComparison(test, cfg)
+		c.noColor = true
+		if diff, ok, err := c.compare(expected, result.Data); err == nil && !ok {
+			f.Diff = diff
+		}
+	}
+
+	line, err := encodeJSON(f)
+	if err != nil {
+		t.Logf("encoding failure: %s", err)
+		return
+	}
+	t.Log(string(line))
+}
+
+// jsonValue returns data as a raw JSON value if it is valid JSON, and as a string otherwise, so
+// that it can be embedded in a JSON document. Empty data is returned as nil.
+func jsonValue(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	if !json.Valid(data) {
+		return string(data)
+	}
+	return json.RawMessage(data)
+}
+
+// maxQuerySummary is the length beyond which queries are truncated in failure messages.
+const maxQuerySummary = 80
+
+// prefixedTB prefixes every message logged through it, so that the failures of a test identify
+// the query that ran without scrolling back to the test table.
+type prefixedTB struct {
+	testing.TB
+	prefix string
+}
+
+// withFailurePrefix returns t prefixing its messages with the name of test, if any, and its
+// operation name or, lacking one, the start of its query.
+func withFailurePrefix(t testing.TB, test *Test) testing.TB {
+	var parts []string
+	if test.Name != "" {
+		parts = append(parts, test.Name)
+	}
+	if test.OperationName != "" {
+		parts = append(parts, "operation "+test.OperationName)
+	} else if q := querySummary(test.Query); q != "" {
+		parts = append(parts, fmt.Sprintf("query %q", q))
+	}
+	if len(parts) == 0 {
+		return t
+	}
+	return &prefixedTB{TB: t, prefix: strings.Join(parts, ", ") + ": "}
+}
+
+// querySummary returns query on a single line, truncated to maxQuerySummary runes.
+func querySummary(query string) string {
+	r := []rune(strings.Join(strings.Fields(query), " "))
+	if len(r) > maxQuerySummary {
+		return string(r[:maxQuerySummary]) + "..."
+	}
+	return string(r)
+}
+
+func (t *prefixedTB) Log(args ...interface{}) {
+	t.TB.Helper()
+	t.TB.Log(t.prefix + fmt.Sprint(args...))
+}
+
+func (t *prefixedTB) Logf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.TB.Log(t.prefix + fmt.Sprintf(format, args...))
+}
+
+func (t *prefixedTB) Error(args ...interface{}) {
+	t.TB.Helper()
+	t.TB.Error(t.prefix + fmt.Sprint(args...))
+}
+
+func (t *prefixedTB) Errorf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.TB.Error(t.prefix + fmt.Sprintf(format, args...))
+}
+
+func (t *prefixedTB) Fatal(args ...interface{}) {
+	t.TB.Helper()
+	t.TB.Fatal(t.prefix + fmt.Sprint(args...))
+}
+
+func (t *prefixedTB) Fatalf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.TB.Fatal(t.prefix + fmt.Sprintf(format, args...))
+}
+
+// quietTB holds back the messages logged through it until the test fails, see WithQuiet.
+type quietTB struct {
+	testing.TB
+
+	mu   sync.Mutex
+	logs []string
+}
+
+func (t *quietTB) Log(args ...interface{}) {
+	t.TB.Helper()
+	t.log(fmt.Sprint(args...))
+}
+
+func (t *quietTB) Logf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.log(fmt.Sprintf(format, args...))
+}
+
+func (t *quietTB) Error(args ...interface{}) {
+	t.TB.Helper()
+	t.flush()
+	t.TB.Error(args...)
+}
+
+func (t *quietTB) Errorf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.flush()
+	t.TB.Errorf(format, args...)
+}
+
+func (t *quietTB) Fatal(args ...interface{}) {
+	t.TB.Helper()
+	t.flush()
+	t.TB.Fatal(args...)
+}
+
+func (t *quietTB) Fatalf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.flush()
+	t.TB.Fatalf(format, args...)
+}
+
+func (t *quietTB) Fail() {
+	t.TB.Helper()
+	t.flush()
+	t.TB.Fail()
+}
+
+// log holds back s, or logs it right away if the test already failed.
+func (t *quietTB) log(s string) {
+	t.TB.Helper()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.TB.Failed() {
+		t.TB.Log(s)
+		return
+	}
+	t.logs = append(t.logs, s)
+}
+
+// flush logs the messages held back so far.
+func (t *quietTB) flush() {
+	t.TB.Helper()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.logs {
+		t.TB.Log(s)
+	}
+	t.logs = nil
+}
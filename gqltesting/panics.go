@@ -0,0 +1,97 @@
+package gqltesting
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/log"
+)
+
+// CapturePanics wraps logger so that RunTest fails with the value, the field path and the stack
+// trace of any panic recovered from a resolver, rather than with a mismatch on the generic error
+// the panic is turned into. Install it when building the schema under test:
+//
+//	schema := graphql.MustParseSchema(sdl, resolver, graphql.Logger(gqltesting.CapturePanics(nil)))
+//
+// Panics are passed on to logger as well. If logger is nil, they are only reported to the test, or
+// logged by log.DefaultLogger when the schema is used outside of this package's helpers.
+func CapturePanics(logger log.Logger) log.Logger {
+	return capturingLogger{logger}
+}
+
+type capturingLogger struct {
+	log.Logger
+}
+
+func (l capturingLogger) LogPanic(ctx context.Context, value interface{}) {
+	rec, ok := ctx.Value(panicRecorderKey{}).(*panicRecorder)
+	if ok {
+		rec.add(value, debug.Stack())
+	}
+	switch {
+	case l.Logger != nil:
+		l.Logger.LogPanic(ctx, value)
+	case !ok:
+		(&log.DefaultLogger{}).LogPanic(ctx, value)
+	}
+}
+
+type panicRecorderKey struct{}
+
+// panicRecorder collects the panics captured during a test.
+type panicRecorder struct {
+	mu     sync.Mutex
+	panics []*capturedPanic
+}
+
+type capturedPanic struct {
+	value string
+	stack []byte
+}
+
+func withPanicRecorder(ctx context.Context) (context.Context, *panicRecorder) {
+	rec := &panicRecorder{}
+	return context.WithValue(ctx, panicRecorderKey{}, rec), rec
+}
+
+func (r *panicRecorder) add(value interface{}, stack []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.panics = append(r.panics, &capturedPanic{value: fmt.Sprint(value), stack: stack})
+}
+
+// check fails the test if any resolver panicked, reporting each panic with its stack trace and the
+// path of the field whose resolver panicked. The logger is not told the path, so it is taken from
+// the error the executor turned the panic into, among errs; panics with the same value are matched
+// with their errors in order.
+func (r *panicRecorder) check(t testing.TB, errs []*errors.QueryError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.panics) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d resolver panic(s):", len(r.panics))
+	matched := make([]bool, len(errs))
+	for _, p := range r.panics {
+		fmt.Fprintf(&b, "\n\npanic: %s", p.value)
+		message := fmt.Sprintf("graphql: panic occurred: %s", p.value)
+		for i, err := range errs {
+			if !matched[i] && err.Message == message {
+				matched[i] = true
+				if len(err.Path) > 0 {
+					fmt.Fprintf(&b, " (path: %v)", err.Path)
+				}
+				break
+			}
+		}
+		fmt.Fprintf(&b, "\n%s", p.stack)
+	}
+	t.Fatal(b.String())
+}
@@ -0,0 +1,104 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// checkPartial asserts that every error with a path nulled out the data at that path, either
+// directly or, when the errored field is non-null, by propagating to one of its ancestors.
+func checkPartial(t testing.TB, errs []*errors.QueryError, data []byte) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("got: %s", invalidJSON(err, data))
+	}
+
+	for _, err := range errs {
+		if len(err.Path) == 0 {
+			continue
+		}
+		if _, ok, walkErr := nullAlongPath(v, err.Path); walkErr != nil {
+			t.Errorf("error %q: %s", err.Message, walkErr)
+		} else if !ok {
+			t.Errorf("error %q: data at path %v is not null; the resolver returned a value despite the error", err.Message, err.Path)
+		}
+	}
+}
+
+// nullAlongPath follows an error path through the decoded data and returns the length of the
+// shortest prefix of the path whose value is null, and whether there is such a prefix. It returns
+// an error if the path does not exist in the data.
+func nullAlongPath(v interface{}, path []interface{}) (int, bool, error) {
+	for i, seg := range path {
+		if v == nil {
+			return i, true, nil
+		}
+		switch node := v.(type) {
+		case map[string]interface{}:
+			key, ok := seg.(string)
+			if !ok {
+				return 0, false, fmt.Errorf("path %v: segment %d is not a field name", path, i)
+			}
+			if v, ok = node[key]; !ok {
+				return 0, false, fmt.Errorf("path %v: no field %q in data", path, key)
+			}
+		case []interface{}:
+			n, ok := toFloat(seg)
+			if !ok || int(n) < 0 || int(n) >= len(node) {
+				return 0, false, fmt.Errorf("path %v: segment %d is not an index into a list of %d elements", path, i, len(node))
+			}
+			v = node[int(n)]
+		default:
+			return 0, false, fmt.Errorf("path %v: segment %d goes past a leaf value", path, i)
+		}
+	}
+	return len(path), v == nil, nil
+}
+
+// AssertNullBubbling asserts that the error of a non-null field propagated as the spec requires:
+// result must hold an error at errorPath, the path of the field whose resolver failed, and the
+// data must be null at nullPath, the nearest nullable ancestor of that field, while no ancestor
+// above it is null. An empty nullPath asserts that the null propagated to the root, leaving the
+// data null. nullPath must be a prefix of errorPath, which it equals if the field is nullable.
+func AssertNullBubbling(t *testing.T, result *graphql.Response, errorPath, nullPath []interface{}) {
+	t.Helper()
+	if len(nullPath) > len(errorPath) {
+		t.Fatalf("null path %v is not a prefix of error path %v", nullPath, errorPath)
+	}
+	if _, ok := firstPathDifference(nullPath, errorPath[:len(nullPath)]); !ok {
+		t.Fatalf("null path %v is not a prefix of error path %v", nullPath, errorPath)
+	}
+
+	found := false
+	var paths []string
+	for _, err := range result.Errors {
+		if _, ok := firstPathDifference(errorPath, err.Path); ok {
+			found = true
+		}
+		paths = append(paths, fmt.Sprint(err.Path))
+	}
+	if !found {
+		t.Errorf("no error at path %v; got errors at %s", errorPath, strings.Join(paths, ", "))
+	}
+
+	var v interface{}
+	if len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, &v); err != nil {
+			t.Fatalf("got: %s", invalidJSON(err, result.Data))
+		}
+	}
+	depth, null, err := nullAlongPath(v, errorPath)
+	switch {
+	case err != nil:
+		t.Error(err)
+	case !null:
+		t.Errorf("data at path %v is not null; the resolver returned a value despite the error", errorPath)
+	case depth != len(nullPath):
+		t.Errorf("the null propagated to %v, want %v", errorPath[:depth], nullPath)
+	}
+}
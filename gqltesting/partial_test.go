@@ -0,0 +1,38 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNullAlongPath(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{"user": {"name": "Alice", "email": null}, "friends": [{"name": null}, null]}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    []interface{}
+		depth   int
+		null    bool
+		wantErr bool
+	}{
+		{path: []interface{}{"user", "email"}, depth: 2, null: true},
+		{path: []interface{}{"user", "name"}, depth: 2, null: false},
+		{path: []interface{}{"friends", 0, "name"}, depth: 3, null: true},
+		{path: []interface{}{"friends", float64(1), "name"}, depth: 2, null: true},
+		{path: []interface{}{"user", "phone"}, wantErr: true},
+		{path: []interface{}{"friends", 5}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		depth, null, err := nullAlongPath(data, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got error %v, want error %t", tt.path, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (depth != tt.depth || null != tt.null) {
+			t.Errorf("%v: got (%d, %t), want (%d, %t)", tt.path, depth, null, tt.depth, tt.null)
+		}
+	}
+}
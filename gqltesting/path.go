@@ -0,0 +1,133 @@
+package gqltesting
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A path addresses values inside a decoded JSON result using dot/bracket syntax, for example
+// "data.users[].id" or "data.users[0].name". Paths are written relative to the response, so a
+// leading "data" segment refers to the root of the result data and may be omitted. An empty pair
+// of brackets matches every element of a list.
+type path []pathSegment
+
+type pathSegment struct {
+	key   string
+	index int  // list index, used when isIndex is set
+	all   bool // matches every list element
+}
+
+func (s pathSegment) isIndex() bool {
+	return s.key == "" && !s.all
+}
+
+func parsePath(p string) path {
+	var segs path
+	for i, part := range strings.Split(p, ".") {
+		name := part
+		var brackets string
+		if j := strings.IndexByte(part, '['); j >= 0 {
+			name, brackets = part[:j], part[j:]
+		}
+		if i == 0 && name == "data" {
+			name = ""
+		} else if name != "" {
+			segs = append(segs, pathSegment{key: name})
+		}
+
+		for brackets != "" {
+			end := strings.IndexByte(brackets, ']')
+			if end < 0 {
+				break
+			}
+			inner := brackets[1:end]
+			if inner == "" {
+				segs = append(segs, pathSegment{all: true})
+			} else if n, err := strconv.Atoi(inner); err == nil {
+				segs = append(segs, pathSegment{index: n})
+			}
+			brackets = brackets[end+1:]
+		}
+	}
+	return segs
+}
+
+// transform replaces every value addressed by p with the result of fn, returning the updated tree.
+func (p path) transform(v interface{}, fn func(interface{}) interface{}) interface{} {
+	if len(p) == 0 {
+		return fn(v)
+	}
+
+	seg, rest := p[0], p[1:]
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if seg.key == "" {
+			return v
+		}
+		if child, ok := v[seg.key]; ok {
+			v[seg.key] = rest.transform(child, fn)
+		}
+		return v
+	case []interface{}:
+		switch {
+		case seg.all:
+			for i := range v {
+				v[i] = rest.transform(v[i], fn)
+			}
+		case seg.isIndex() && seg.index >= 0 && seg.index < len(v):
+			v[seg.index] = rest.transform(v[seg.index], fn)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// remove deletes the object fields addressed by p, returning the updated tree.
+func (p path) remove(v interface{}) interface{} {
+	if len(p) == 0 {
+		return v
+	}
+	last := p[len(p)-1]
+	if last.key == "" {
+		return v
+	}
+	return p[:len(p)-1].transform(v, func(v interface{}) interface{} {
+		if obj, ok := v.(map[string]interface{}); ok {
+			delete(obj, last.key)
+		}
+		return v
+	})
+}
+
+// lookup returns every value addressed by p.
+func (p path) lookup(v interface{}) []interface{} {
+	var found []interface{}
+	p.transform(v, func(v interface{}) interface{} {
+		found = append(found, v)
+		return v
+	})
+	return found
+}
+
+// matchesErrorPath reports whether the error path got, made of field names and list indexes,
+// is addressed by p.
+func (p path) matchesErrorPath(got []interface{}) bool {
+	if len(p) != len(got) {
+		return false
+	}
+	for i, seg := range p {
+		switch {
+		case seg.key != "":
+			if key, ok := got[i].(string); !ok || key != seg.key {
+				return false
+			}
+		default:
+			n, ok := toFloat(got[i])
+			if !ok || !seg.all && n != float64(seg.index) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package gqltesting
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want path
+	}{
+		{"data", nil},
+		{"data.users", path{{key: "users"}}},
+		{"users", path{{key: "users"}}},
+		{"data.users[].id", path{{key: "users"}, {all: true}, {key: "id"}}},
+		{"data.users[1].friends[]", path{{key: "users"}, {index: 1}, {key: "friends"}, {all: true}}},
+		{"data.user.data", path{{key: "user"}, {key: "data"}}},
+	}
+
+	for _, tt := range tests {
+		if got := parsePath(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePath(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPath_matchesErrorPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		got   []interface{}
+		match bool
+	}{
+		{"users[1].name", []interface{}{"users", 1, "name"}, true},
+		{"data.users[1].name", []interface{}{"users", float64(1), "name"}, true},
+		{"users[].name", []interface{}{"users", 7, "name"}, true},
+		{"users[0].name", []interface{}{"users", 1, "name"}, false},
+		{"users[].name", []interface{}{"users", "name"}, false},
+		{"users", []interface{}{"users", 0}, false},
+	}
+
+	for _, tt := range tests {
+		if got := parsePath(tt.path).matchesErrorPath(tt.got); got != tt.match {
+			t.Errorf("%q matching %v: got %v, want %v", tt.path, tt.got, got, tt.match)
+		}
+	}
+}
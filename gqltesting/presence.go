@@ -0,0 +1,98 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// AssertFieldPresent asserts that the data of result has a field at the dot-path p, written as for
+// FieldMatchers, such as "user.friends[].name". A path through a list with empty brackets must
+// reach the field in every element. A field resolved to null is present. This tells apart the
+// selections produced by directives such as @skip and @include without comparing the whole result.
+func AssertFieldPresent(t *testing.T, result *graphql.Response, p string) {
+	t.Helper()
+	parents, field := fieldParents(t, result, p)
+	if len(parents) == 0 {
+		t.Errorf("%s: no such field in result", p)
+		return
+	}
+	for _, parent := range parents {
+		obj, ok := parent.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: got %v, want an object with field %q", p, parent, field)
+			continue
+		}
+		if _, ok := obj[field]; !ok {
+			t.Errorf("%s: no such field in result", p)
+			return
+		}
+	}
+}
+
+// AssertFieldAbsent asserts that the data of result has no field at the dot-path p, written as for
+// AssertFieldPresent. A path through a list with empty brackets must not reach the field in any
+// element.
+func AssertFieldAbsent(t *testing.T, result *graphql.Response, p string) {
+	t.Helper()
+	parents, field := fieldParents(t, result, p)
+	for _, parent := range parents {
+		if obj, ok := parent.(map[string]interface{}); ok {
+			if v, ok := obj[field]; ok {
+				t.Errorf("%s: got field with value %v, want no such field in result", p, v)
+				return
+			}
+		}
+	}
+}
+
+// fieldParents decodes the data of result and returns the values holding the field the path p
+// ends with, along with the name of that field.
+func fieldParents(t testing.TB, result *graphql.Response, p string) ([]interface{}, string) {
+	t.Helper()
+	segs := parsePath(p)
+	if len(segs) == 0 || segs[len(segs)-1].key == "" {
+		t.Fatalf("path %q does not end with a field name", p)
+	}
+
+	var v interface{}
+	if len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, &v); err != nil {
+			t.Fatalf("got: %s", invalidJSON(err, result.Data))
+		}
+	}
+	return segs[:len(segs)-1].lookup(v), segs[len(segs)-1].key
+}
+
+// AssertErrorAtPath asserts that at least one error of result has the path p, whatever its message
+// and extensions. It is the complement of AssertFieldAbsent for fields that failed with errors not
+// under the test's control, such as those of a library. The path is an error path such as
+// []interface{}{"users", 1, "name"}, or a dot-path such as "users[1].name", where empty brackets
+// match any index.
+func AssertErrorAtPath(t *testing.T, result *graphql.Response, p interface{}) {
+	t.Helper()
+	var match func([]interface{}) bool
+	switch p := p.(type) {
+	case []interface{}:
+		match = func(got []interface{}) bool {
+			_, ok := firstPathDifference(p, got)
+			return ok
+		}
+	case string:
+		match = parsePath(p).matchesErrorPath
+	default:
+		t.Fatalf("path %v is neither a []interface{} nor a string", p)
+	}
+
+	var paths []string
+	for _, err := range result.Errors {
+		if match(err.Path) {
+			return
+		}
+		paths = append(paths, fmt.Sprint(err.Path))
+	}
+	t.Errorf("no error at path %v; got errors at %s", p, strings.Join(paths, ", "))
+}
@@ -0,0 +1,184 @@
+package gqltesting
+
+import (
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/testhook"
+)
+
+// QueryDoc is a read-only view of a parsed query document, as returned by ParseAndValidate.
+type QueryDoc struct {
+	Operations []*QueryOperation
+	Fragments  []*QueryFragment
+}
+
+// QueryOperation is an operation of a query document.
+type QueryOperation struct {
+	Type       string // "query", "mutation" or "subscription"
+	Name       string
+	Variables  []string // names of the variables, without "$"
+	Directives []string
+	Selections []*QuerySelection
+	Location   errors.Location
+}
+
+// QueryFragment is a fragment definition of a query document.
+type QueryFragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []string
+	Selections    []*QuerySelection
+	Location      errors.Location
+}
+
+// The kinds of QuerySelection.
+const (
+	FieldSelection          = "field"
+	InlineFragmentSelection = "inline fragment"
+	FragmentSpreadSelection = "fragment spread"
+)
+
+// QuerySelection is an element of a selection set: a field, an inline fragment or a fragment
+// spread, as given by Kind.
+type QuerySelection struct {
+	Kind string
+
+	// Alias, Name and Arguments describe a field; Alias equals Name unless the field is aliased.
+	// Name is also the name of a spread fragment.
+	Alias     string
+	Name      string
+	Arguments []string
+
+	// TypeCondition is the type an inline fragment applies to, if any.
+	TypeCondition string
+
+	Directives []string
+	Selections []*QuerySelection
+}
+
+// ParseAndValidate parses query and validates the resulting document against schema, as
+// Schema.Exec does before executing it. It returns the parsed document, unless query has syntax
+// errors, along with the validation errors. Since no values are given for the variables, the
+// errors of the VariablesOfCorrectType rule, which checks those values, such as reporting that a
+// required variable was not provided, are left out; every other rule applies, including the
+// ones checking the declarations and default values of the variables.
+func ParseAndValidate(schema *graphql.Schema, queryString string) (*QueryDoc, []*errors.QueryError) {
+	doc, err := query.Parse(queryString)
+	if err != nil {
+		return nil, []*errors.QueryError{err}
+	}
+	var errs []*errors.QueryError
+	for _, err := range testhook.ValidateDocument(schema, doc, nil) {
+		if err.Rule != "VariablesOfCorrectType" {
+			errs = append(errs, err)
+		}
+	}
+	return newQueryDoc(doc), errs
+}
+
+// HasField reports whether any operation or fragment of the document selects the field name,
+// such as "__typename", at any depth.
+func (d *QueryDoc) HasField(name string) bool {
+	for _, op := range d.Operations {
+		if hasField(op.Selections, name) {
+			return true
+		}
+	}
+	for _, frag := range d.Fragments {
+		if hasField(frag.Selections, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasField(sels []*QuerySelection, name string) bool {
+	for _, sel := range sels {
+		if sel.Kind == FieldSelection && sel.Name == name || hasField(sel.Selections, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func newQueryDoc(doc *query.Document) *QueryDoc {
+	d := &QueryDoc{}
+	for _, op := range doc.Operations {
+		o := &QueryOperation{
+			Type:       operationType(op.Type),
+			Name:       op.Name.Name,
+			Directives: directiveNames(op.Directives),
+			Selections: newSelections(op.Selections),
+			Location:   op.Loc,
+		}
+		for _, v := range op.Vars {
+			o.Variables = append(o.Variables, v.Name.Name)
+		}
+		d.Operations = append(d.Operations, o)
+	}
+	for _, frag := range doc.Fragments {
+		d.Fragments = append(d.Fragments, &QueryFragment{
+			Name:          frag.Name.Name,
+			TypeCondition: frag.On.Name,
+			Directives:    directiveNames(frag.Directives),
+			Selections:    newSelections(frag.Selections),
+			Location:      frag.Loc,
+		})
+	}
+	return d
+}
+
+func operationType(t query.OperationType) string {
+	switch t {
+	case query.Mutation:
+		return "mutation"
+	case query.Subscription:
+		return "subscription"
+	default:
+		return "query"
+	}
+}
+
+func newSelections(sels []query.Selection) []*QuerySelection {
+	var out []*QuerySelection
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *query.Field:
+			s := &QuerySelection{
+				Kind:       FieldSelection,
+				Alias:      sel.Alias.Name,
+				Name:       sel.Name.Name,
+				Directives: directiveNames(sel.Directives),
+				Selections: newSelections(sel.Selections),
+			}
+			for _, arg := range sel.Arguments {
+				s.Arguments = append(s.Arguments, arg.Name.Name)
+			}
+			out = append(out, s)
+		case *query.InlineFragment:
+			out = append(out, &QuerySelection{
+				Kind:          InlineFragmentSelection,
+				TypeCondition: sel.On.Name,
+				Directives:    directiveNames(sel.Directives),
+				Selections:    newSelections(sel.Selections),
+			})
+		case *query.FragmentSpread:
+			out = append(out, &QuerySelection{
+				Kind:       FragmentSpreadSelection,
+				Name:       sel.Name.Name,
+				Directives: directiveNames(sel.Directives),
+			})
+		}
+	}
+	return out
+}
+
+func directiveNames(directives common.DirectiveList) []string {
+	var names []string
+	for _, d := range directives {
+		names = append(names, d.Name.Name)
+	}
+	return names
+}
@@ -0,0 +1,113 @@
+package gqltesting
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// RunRejectionTest asserts that the operation of test, which must be of the given operationType,
+// "query", "mutation" or "subscription", is rejected: it must return errors, each of whose message
+// contains message, and none of its top-level fields may resolve to a value. It codifies
+// authorization boundaries such as a read-only context, configured through the options, refusing
+// mutations:
+//
+//	gqltesting.RunRejectionTest(t, &gqltesting.Test{
+//		Schema: schema,
+//		Query:  `mutation { deleteUser(id: "1") }`,
+//	}, "mutation", "read-only", gqltesting.WithContextValue(readOnlyKey{}, true))
+//
+// Subscriptions are rejected if their first payload is. The expectations of test, such as
+// ExpectedResult, are ignored; run the same test with RunTest to assert that an operation is
+// handled where it is allowed.
+func RunRejectionTest(t *testing.T, test *Test, operationType, message string, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	op := selectedOperation(t, test)
+	if op.Type != operationType {
+		t.Fatalf("the operation is a %s, want a %s", op.Type, operationType)
+	}
+
+	parent := testContext(test, cfg)
+	ctx, cancel := timeoutContext(parent, testTimeout(parent, cfg))
+	defer cancel()
+	variables := testVariables(t, test)
+	var result *graphql.Response
+	if op.Type == "subscription" {
+		result = firstPayload(t, ctx, test, variables)
+	} else {
+		var err error
+		if result, err = cfg.execFunc()(ctx, test, variables); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(result.Errors) == 0 {
+		t.Fatalf("got data %s and no errors, want the %s to be rejected with %q", result.Data, op.Type, message)
+	}
+	for _, err := range result.Errors {
+		if !strings.Contains(err.Message, message) {
+			t.Errorf("got error %q, want it to contain %q", err.Message, message)
+		}
+	}
+	if fields := resolvedFields(t, result.Data); len(fields) > 0 {
+		t.Errorf("the fields %s resolved to values despite the rejection", strings.Join(fields, ", "))
+	}
+}
+
+// selectedOperation returns the operation of the document of test that executing it selects.
+func selectedOperation(t testing.TB, test *Test) *QueryOperation {
+	doc, errs := ParseAndValidate(test.Schema, test.Query)
+	if doc == nil {
+		t.Fatalf("parsing query: %s", errorMessages(errs))
+	}
+	for _, op := range doc.Operations {
+		if op.Name == test.OperationName || test.OperationName == "" && len(doc.Operations) == 1 {
+			return op
+		}
+	}
+	if test.OperationName == "" {
+		t.Fatalf("the document has %d operations, set OperationName to select one", len(doc.Operations))
+	}
+	t.Fatalf("the document has no operation named %q", test.OperationName)
+	return nil
+}
+
+// firstPayload subscribes to the subscription of test and returns its first payload.
+func firstPayload(t testing.TB, ctx context.Context, test *Test, variables map[string]interface{}) *graphql.Response {
+	c, err := test.Schema.Subscribe(ctx, test.Query, test.OperationName, variables)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	select {
+	case res, ok := <-c:
+		if !ok {
+			t.Fatal("subscription closed without any payload")
+		}
+		return res.(*graphql.Response)
+	case <-ctx.Done():
+		t.Fatalf("%s while waiting for subscription", ctx.Err())
+	}
+	return nil
+}
+
+// resolvedFields returns the top-level fields of data that are not null, in sorted order.
+func resolvedFields(t testing.TB, data json.RawMessage) []string {
+	if len(data) == 0 || isJSONNull(data) {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("got: %s", invalidJSON(err, data))
+	}
+	var resolved []string
+	for _, name := range sortedRawKeys(fields) {
+		if !isJSONNull(fields[name]) {
+			resolved = append(resolved, name)
+		}
+	}
+	return resolved
+}
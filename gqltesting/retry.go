@@ -0,0 +1,44 @@
+package gqltesting
+
+import (
+	"context"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// A TransientFunc reports whether err is caused by a transient failure of an external
+// dependency, such as a timeout, that makes it worth executing the query again.
+type TransientFunc func(err *errors.QueryError) bool
+
+// retrying returns exec executing again, up to retries times and waiting backoff in between, as
+// long as the response contains an error classified as transient. The last response is returned
+// whatever its errors, so that it is checked as usual.
+func retrying(exec execFunc, retries int, backoff time.Duration, transient TransientFunc) execFunc {
+	return func(ctx context.Context, test *Test, variables map[string]interface{}) (*graphql.Response, error) {
+		for attempt := 0; ; attempt++ {
+			result, err := exec(ctx, test, variables)
+			if err != nil || attempt == retries || !hasTransientError(result, transient) {
+				return result, err
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return result, nil
+			}
+		}
+	}
+}
+
+func hasTransientError(result *graphql.Response, transient TransientFunc) bool {
+	for _, err := range result.Errors {
+		if transient(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package gqltesting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// runTestBothWays runs test in process and through an HTTP round trip, as subtests.
+func runTestBothWays(t *testing.T, test *Test, cfg *runConfig) {
+	t.Run("Exec", func(t *testing.T) {
+		c := *cfg
+		runTest(t, test, &c)
+	})
+	t.Run("HTTP", func(t *testing.T) {
+		c := *cfg
+		c.exec = httpExec
+		runTest(t, httpTest(test), &c)
+	})
+}
+
+// httpTest returns a copy of test whose expected errors omit the fields that are not serialized.
+func httpTest(test *Test) *Test {
+	c := *test
+	if test.ExpectedErrors != nil {
+		c.ExpectedErrors = make([]*errors.QueryError, len(test.ExpectedErrors))
+		for i, err := range test.ExpectedErrors {
+			e := *err
+			e.Rule = ""
+			e.ResolverError = nil
+			c.ExpectedErrors[i] = &e
+		}
+	}
+	return &c
+}
+
+// httpExec executes test by POSTing it to an httptest.Server serving relay.Handler, which is shut
+// down before httpExec returns. The server handles the request with ctx.
+func httpExec(ctx context.Context, test *Test, variables map[string]interface{}) (*graphql.Response, error) {
+	return postJSON(ctx, &relay.Handler{Schema: test.Schema}, map[string]interface{}{
+		"query":         test.Query,
+		"operationName": test.OperationName,
+		"variables":     variables,
+	})
+}
+
+// postJSON POSTs body as JSON to an httptest.Server serving h, which is shut down before postJSON
+// returns, and decodes the GraphQL response. The server handles the request with ctx.
+func postJSON(ctx context.Context, h http.Handler, body map[string]interface{}) (*graphql.Response, error) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", srv.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := srv.Client().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP round trip: %s", err)
+	}
+	defer resp.Body.Close()
+
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP round trip: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP round trip: got status %s: %s", resp.Status, data)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		return nil, fmt.Errorf("HTTP round trip: got Content-Type %q, want application/json", ct)
+	}
+
+	var result graphql.Response
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("HTTP round trip: invalid response: %s: %s", err, data)
+	}
+	return &result, nil
+}
@@ -0,0 +1,34 @@
+package gqltesting
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// AssertSchemaError parses sdl with the given resolver and checks that graphql.ParseSchema fails
+// with an error whose message contains wantMsg. If locations are given, the error must also report
+// exactly these locations in the SDL.
+func AssertSchemaError(t *testing.T, sdl string, resolver interface{}, wantMsg string, locations ...errors.Location) {
+	t.Helper()
+	_, err := graphql.ParseSchema(sdl, resolver)
+	if err == nil {
+		t.Fatalf("schema parsed without error, want error containing %q", wantMsg)
+	}
+	if !strings.Contains(err.Error(), wantMsg) {
+		t.Errorf("got schema error %q, want error containing %q", err, wantMsg)
+	}
+	if len(locations) == 0 {
+		return
+	}
+	qe, ok := err.(*errors.QueryError)
+	if !ok {
+		t.Fatalf("schema error %q has no locations, want %v", err, locations)
+	}
+	if !reflect.DeepEqual(qe.Locations, locations) {
+		t.Errorf("got schema error locations %v, want %v", qe.Locations, locations)
+	}
+}
@@ -0,0 +1,297 @@
+package gqltesting
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// AssertSDL prints schema as SDL and compares it with the golden file at goldenPath, which is
+// rewritten when running go test -update or with UpdateEnv set. The graphql package cannot print
+// a schema back, so the SDL is rendered from its introspection, in a canonical form: types,
+// fields, arguments, enum values, interfaces, union members and directives are sorted by name,
+// the built-in scalars and directives are left out, and descriptions and deprecations are kept.
+// Differences in whitespace between the lines of the golden file, such as trailing spaces or
+// blank lines, are ignored. Unlike RunIntrospectionSnapshot, the golden file reads like the
+// schema itself, so changes to it are easy to review.
+func AssertSDL(t *testing.T, schema *graphql.Schema, goldenPath string) {
+	t.Helper()
+	got := printSDL(schema.Inspect())
+	if updating() {
+		updateGolden(t, goldenPath, []byte(got), func(old []byte) []byte {
+			return []byte(normalizeSDL(string(old)))
+		})
+		return
+	}
+
+	data, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading golden file: %s (run with -update or %s=1 to create it)", err, UpdateEnv)
+	}
+	want := normalizeSDL(string(data))
+	if want == got {
+		return
+	}
+	diff, err := DiffFunc([]byte(want), []byte(got))
+	if err != nil || diff == "" {
+		diff = fmt.Sprintf("got:\n%s\nwant:\n%s", got, want)
+	}
+	t.Errorf("schema SDL differs from golden file %s:\n%s", goldenPath, diff)
+}
+
+// normalizeSDL strips trailing whitespace from every line of sdl, collapses runs of blank lines
+// and ends it with a single newline, as printSDL does.
+func normalizeSDL(sdl string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.Replace(sdl, "\r\n", "\n", -1), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// builtinTypes and builtinDirectives are defined by every schema, so printSDL leaves them out.
+var (
+	builtinTypes      = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+	builtinDirectives = map[string]bool{"include": true, "skip": true, "deprecated": true}
+)
+
+// defaultDeprecationReason is the reason of @deprecated when none is given.
+const defaultDeprecationReason = "No longer supported"
+
+// printSDL renders the introspection of a schema as canonical SDL, see AssertSDL.
+func printSDL(s *introspection.Schema) string {
+	var defs []string
+	if def := schemaDefinition(s); def != "" {
+		defs = append(defs, def)
+	}
+
+	var directives []string
+	for _, d := range s.Directives() {
+		if !builtinDirectives[d.Name()] {
+			directives = append(directives, printDirective(d))
+		}
+	}
+	sort.Strings(directives)
+
+	types := make(map[string]*introspection.Type)
+	var names []string
+	for _, typ := range s.Types() {
+		name := *typ.Name()
+		if builtinTypes[name] || strings.HasPrefix(name, "__") {
+			continue
+		}
+		types[name] = typ
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		defs = append(defs, printType(types[name]))
+	}
+	defs = append(defs, directives...)
+	return strings.Join(defs, "\n\n") + "\n"
+}
+
+// schemaDefinition returns the schema definition naming the root operation types, or "" if they
+// all have their default names, in which case the definition is omitted.
+func schemaDefinition(s *introspection.Schema) string {
+	roots := []struct {
+		op, name string
+		typ      *introspection.Type
+	}{
+		{"query", "Query", s.QueryType()},
+		{"mutation", "Mutation", s.MutationType()},
+		{"subscription", "Subscription", s.SubscriptionType()},
+	}
+	custom := false
+	var b strings.Builder
+	b.WriteString("schema {")
+	for _, root := range roots {
+		if root.typ == nil {
+			continue
+		}
+		name := *root.typ.Name()
+		custom = custom || name != root.name
+		fmt.Fprintf(&b, "\n  %s: %s", root.op, name)
+	}
+	b.WriteString("\n}")
+	if !custom {
+		return ""
+	}
+	return b.String()
+}
+
+func printType(typ *introspection.Type) string {
+	var b strings.Builder
+	writeDescription(&b, typ.Description(), "")
+	name := *typ.Name()
+	all := &struct{ IncludeDeprecated bool }{true}
+	switch typ.Kind() {
+	case "SCALAR":
+		fmt.Fprintf(&b, "scalar %s", name)
+	case "OBJECT", "INTERFACE":
+		keyword := "type"
+		if typ.Kind() == "INTERFACE" {
+			keyword = "interface"
+		}
+		fmt.Fprintf(&b, "%s %s", keyword, name)
+		if interfaces := typeNames(typ.Interfaces()); len(interfaces) > 0 {
+			fmt.Fprintf(&b, " implements %s", strings.Join(interfaces, " & "))
+		}
+		fields := append([]*introspection.Field(nil), *typ.Fields(all)...)
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Name() < fields[j].Name() })
+		b.WriteString(" {")
+		for _, f := range fields {
+			b.WriteString("\n")
+			writeDescription(&b, f.Description(), "  ")
+			fmt.Fprintf(&b, "  %s%s: %s", f.Name(), printArgs(f.Args()), typeRef(f.Type()))
+			writeDeprecation(&b, f.IsDeprecated(), f.DeprecationReason())
+		}
+		b.WriteString("\n}")
+	case "UNION":
+		fmt.Fprintf(&b, "union %s = %s", name, strings.Join(typeNames(typ.PossibleTypes()), " | "))
+	case "ENUM":
+		values := append([]*introspection.EnumValue(nil), *typ.EnumValues(all)...)
+		sort.Slice(values, func(i, j int) bool { return values[i].Name() < values[j].Name() })
+		fmt.Fprintf(&b, "enum %s {", name)
+		for _, v := range values {
+			b.WriteString("\n")
+			writeDescription(&b, v.Description(), "  ")
+			fmt.Fprintf(&b, "  %s", v.Name())
+			writeDeprecation(&b, v.IsDeprecated(), v.DeprecationReason())
+		}
+		b.WriteString("\n}")
+	case "INPUT_OBJECT":
+		fmt.Fprintf(&b, "input %s {", name)
+		for _, v := range sortedInputValues(*typ.InputFields()) {
+			b.WriteString("\n")
+			writeDescription(&b, v.Description(), "  ")
+			fmt.Fprintf(&b, "  %s", printInputValue(v))
+		}
+		b.WriteString("\n}")
+	}
+	return b.String()
+}
+
+func printDirective(d *introspection.Directive) string {
+	var b strings.Builder
+	writeDescription(&b, d.Description(), "")
+	locations := append([]string(nil), d.Locations()...)
+	sort.Strings(locations)
+	fmt.Fprintf(&b, "directive @%s%s on %s", d.Name(), printArgs(d.Args()), strings.Join(locations, " | "))
+	return b.String()
+}
+
+// printArgs renders the arguments of a field or directive, sorted by name, or "" if it has none.
+func printArgs(args []*introspection.InputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	printed := make([]string, len(args))
+	for i, arg := range sortedInputValues(args) {
+		printed[i] = printInputValue(arg)
+	}
+	return "(" + strings.Join(printed, ", ") + ")"
+}
+
+func printInputValue(v *introspection.InputValue) string {
+	s := v.Name() + ": " + typeRef(v.Type())
+	if def := v.DefaultValue(); def != nil {
+		s += " = " + *def
+	}
+	return s
+}
+
+func sortedInputValues(values []*introspection.InputValue) []*introspection.InputValue {
+	sorted := append([]*introspection.InputValue(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+	return sorted
+}
+
+// typeRef renders a reference to typ, such as [String!]!.
+func typeRef(typ *introspection.Type) string {
+	switch typ.Kind() {
+	case "NON_NULL":
+		return typeRef(typ.OfType()) + "!"
+	case "LIST":
+		return "[" + typeRef(typ.OfType()) + "]"
+	default:
+		return *typ.Name()
+	}
+}
+
+// typeNames returns the sorted names of types.
+func typeNames(types *[]*introspection.Type) []string {
+	if types == nil {
+		return nil
+	}
+	names := make([]string, len(*types))
+	for i, typ := range *types {
+		names[i] = *typ.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeDescription writes desc, if any, as a string on the lines preceding a definition, using a
+// block string if it spans several lines.
+func writeDescription(b *strings.Builder, desc *string, indent string) {
+	if desc == nil || *desc == "" {
+		return
+	}
+	if !strings.Contains(*desc, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, quoteString(*desc))
+		return
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(*desc, "\n") {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(b, "%s%s\n", indent, strings.Replace(line, `"""`, `\"""`, -1))
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
+
+func writeDeprecation(b *strings.Builder, deprecated bool, reason *string) {
+	if !deprecated {
+		return
+	}
+	if reason == nil || *reason == defaultDeprecationReason {
+		b.WriteString(" @deprecated")
+		return
+	}
+	fmt.Fprintf(b, " @deprecated(reason: %s)", quoteString(*reason))
+}
+
+// quoteString renders s as a GraphQL string literal.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
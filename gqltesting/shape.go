@@ -0,0 +1,69 @@
+package gqltesting
+
+import (
+	"fmt"
+	"testing"
+)
+
+// checkShape fails the test for every object key present in only one of the expected and actual
+// data, at any level, regardless of the values. Both sides are normalized by c first, so that
+// ignored fields and fields checked by FieldMatchers may be left out of the expected data.
+func checkShape(t testing.TB, c *comparison, expected, actual []byte) {
+	got, err := c.decode(actual)
+	if err != nil {
+		t.Fatalf("got: %s", invalidJSON(err, actual))
+	}
+	want, err := c.decode(expected)
+	if err != nil {
+		t.Fatalf("want: invalid JSON: %s", err)
+	}
+	for _, diff := range shapeDiff("data", want, got) {
+		t.Error(diff)
+	}
+}
+
+// shapeDiff describes the keys that differ between the objects of want and got, which are at the
+// path p. List elements are compared pairwise, up to the length of the shorter list.
+func shapeDiff(p string, want, got interface{}) []string {
+	var diffs []string
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, k := range sortedMembers(g) {
+			if _, ok := w[k]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected field %q", p, k))
+			}
+		}
+		for _, k := range sortedMembers(w) {
+			if gv, ok := g[k]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: missing field %q", p, k))
+			} else {
+				diffs = append(diffs, shapeDiff(p+"."+k, w[k], gv)...)
+			}
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i := 0; i < len(w) && i < len(g); i++ {
+			diffs = append(diffs, shapeDiff(fmt.Sprintf("%s[%d]", p, i), w[i], g[i])...)
+		}
+	default:
+		// A leaf, such as a placeholder, standing for an object or a list: all of its keys are
+		// unexpected.
+		if g, ok := got.(map[string]interface{}); ok {
+			for _, k := range sortedMembers(g) {
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected field %q", p, k))
+			}
+		} else if g, ok := got.([]interface{}); ok {
+			for i := range g {
+				diffs = append(diffs, shapeDiff(fmt.Sprintf("%s[%d]", p, i), nil, g[i])...)
+			}
+		}
+	}
+	return diffs
+}
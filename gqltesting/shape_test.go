@@ -0,0 +1,55 @@
+package gqltesting
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckShape(t *testing.T) {
+	tests := []struct {
+		name     string
+		c        *comparison
+		expected string
+		actual   string
+		want     []string
+	}{
+		{
+			name:     "same keys",
+			c:        &comparison{},
+			expected: `{"user": {"id": "1", "name": "Bob"}}`,
+			actual:   `{"user": {"id": "1", "name": "Alice"}}`,
+		},
+		{
+			name:     "extra and missing keys",
+			c:        &comparison{},
+			expected: `{"users": [{"id": "1", "name": "Alice"}]}`,
+			actual:   `{"users": [{"id": "1", "email": "alice@example.com"}, {"id": "2"}]}`,
+			want:     []string{`data.users[0]: unexpected field "email"`, `data.users[0]: missing field "name"`},
+		},
+		{
+			name:     "placeholder for an object",
+			c:        &comparison{placeholder: DefaultPlaceholder},
+			expected: `{"user": "<ANY>"}`,
+			actual:   `{"user": {"id": "1"}}`,
+			want:     []string{`data.user: unexpected field "id"`},
+		},
+		{
+			name:     "ignored fields",
+			c:        &comparison{normalizers: []normalizer{ignoreFields([]string{"user.createdAt"})}},
+			expected: `{"user": {"id": "1"}}`,
+			actual:   `{"user": {"id": "1", "createdAt": "2020-01-01T00:00:00Z"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := runFake(func(tb testing.TB) {
+				checkShape(tb, tt.c, []byte(tt.expected), []byte(tt.actual))
+			})
+			if tb.Failed() != (len(tt.want) > 0) || !reflect.DeepEqual(tb.output, tt.want) {
+				t.Errorf("got failures %q, want %q", tb.output, tt.want)
+			}
+		})
+	}
+}
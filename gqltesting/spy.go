@@ -0,0 +1,67 @@
+package gqltesting
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// SpyResolver records the arguments resolver methods are called with, so that tests can check
+// how the arguments of a field were coerced, including defaults, nested input objects and enums.
+// Since resolvers are plain methods, a spy is installed by wrapping the resolver under test and
+// recording the calls of interest before delegating:
+//
+//	type spyingResolver struct {
+//		*Resolver
+//		spy *gqltesting.SpyResolver
+//	}
+//
+//	func (r *spyingResolver) Search(args searchArgs) []*result {
+//		r.spy.Record("Query.search", args)
+//		return r.Resolver.Search(args)
+//	}
+//
+// A SpyResolver is safe for concurrent use, and its zero value is ready to use.
+type SpyResolver struct {
+	mu    sync.Mutex
+	calls map[string][]interface{}
+}
+
+// Record records a call of the resolver of field, such as "Query.search", with args.
+func (s *SpyResolver) Record(field string, args interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.calls == nil {
+		s.calls = make(map[string][]interface{})
+	}
+	s.calls[field] = append(s.calls[field], args)
+}
+
+// Calls returns the arguments of each recorded call of the resolver of field, in order.
+func (s *SpyResolver) Calls(field string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]interface{}(nil), s.calls[field]...)
+}
+
+// Reset forgets all recorded calls.
+func (s *SpyResolver) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// AssertCalls checks that the resolver of field was called exactly with the given arguments, in
+// order, comparing them with reflect.DeepEqual.
+func (s *SpyResolver) AssertCalls(t *testing.T, field string, want ...interface{}) {
+	t.Helper()
+	got := s.Calls(field)
+	if len(got) != len(want) {
+		t.Fatalf("%s resolved %d times with %+v, want %d times with %+v", field, len(got), got, len(want), want)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("%s call %d: got args %+v, want %+v", field, i+1, got[i], want[i])
+		}
+	}
+}
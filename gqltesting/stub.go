@@ -0,0 +1,188 @@
+package gqltesting
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// ParseStubSchema parses the schema sdl and backs it with a generated stub resolver, so that tests
+// can exercise validation, selection sets, directives and introspection without real resolvers.
+// Every field resolves to a deterministic fake: the zero value of scalars, the first value of
+// enums, a list holding a single fake element, and a fake object for object types, nullable or
+// not. Custom scalars resolve to the empty string.
+//
+// The stub is built with reflection and graphql.UseFieldResolvers, which is added to opts. It
+// cannot implement interfaces, unions or subscriptions, nor object types that refer to themselves,
+// directly or not; ParseStubSchema returns an error for schemas using them.
+func ParseStubSchema(sdl string, opts ...graphql.SchemaOpt) (*graphql.Schema, error) {
+	schema, err := graphql.ParseSchema(sdl, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	inspected := schema.Inspect()
+	if inspected.SubscriptionType() != nil {
+		return nil, fmt.Errorf("stub resolvers do not support subscriptions")
+	}
+
+	b := &stubBuilder{building: make(map[string]bool)}
+	var roots []*introspection.Type
+	for _, root := range []*introspection.Type{inspected.QueryType(), inspected.MutationType()} {
+		if root != nil {
+			roots = append(roots, root)
+		}
+	}
+	resolver, err := b.rootValue(roots)
+	if err != nil {
+		return nil, err
+	}
+	return graphql.ParseSchema(sdl, resolver, append(opts, graphql.UseFieldResolvers())...)
+}
+
+// MustParseStubSchema calls ParseStubSchema and panics on error.
+func MustParseStubSchema(sdl string, opts ...graphql.SchemaOpt) *graphql.Schema {
+	schema, err := ParseStubSchema(sdl, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// stubScalar is the stub value of custom scalars.
+type stubScalar struct{}
+
+func (stubScalar) ImplementsGraphQLType(name string) bool {
+	return true
+}
+
+func (*stubScalar) UnmarshalGraphQL(input interface{}) error {
+	return nil
+}
+
+func (stubScalar) MarshalJSON() ([]byte, error) {
+	return []byte(`""`), nil
+}
+
+type stubBuilder struct {
+	building map[string]bool // object types being built, to detect cycles
+}
+
+// rootValue returns the stub root resolver, which resolves the fields of all root types.
+func (b *stubBuilder) rootValue(roots []*introspection.Type) (interface{}, error) {
+	var fields []*introspection.Field
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		b.building[*root.Name()] = true
+		for _, f := range *root.Fields(&struct{ IncludeDeprecated bool }{true}) {
+			if !seen[f.Name()] {
+				seen[f.Name()] = true
+				fields = append(fields, f)
+			}
+		}
+	}
+	v, err := b.objectValue("", fields)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// objectValue returns a pointer to a struct holding a fake value for each of fields of the object
+// type typeName, which is empty for the root resolver.
+func (b *stubBuilder) objectValue(typeName string, fields []*introspection.Field) (reflect.Value, error) {
+	if typeName != "" {
+		if b.building[typeName] {
+			return reflect.Value{}, fmt.Errorf("stub resolvers do not support the recursive type %q", typeName)
+		}
+		b.building[typeName] = true
+		defer delete(b.building, typeName)
+	}
+
+	structFields := make([]reflect.StructField, len(fields))
+	values := make([]reflect.Value, len(fields))
+	names := make(map[string]string)
+	for i, f := range fields {
+		name := goFieldName(f.Name())
+		if other, ok := names[name]; ok || name == "" {
+			return reflect.Value{}, fmt.Errorf("stub resolvers can not tell the fields %q and %q apart", other, f.Name())
+		}
+		names[name] = f.Name()
+
+		v, err := b.value(f.Type(), false)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		structFields[i] = reflect.StructField{Name: name, Type: v.Type()}
+		values[i] = v
+	}
+
+	obj := reflect.New(reflect.StructOf(structFields))
+	for i, v := range values {
+		obj.Elem().Field(i).Set(v)
+	}
+	return obj, nil
+}
+
+// value returns the fake value of a field of type t.
+func (b *stubBuilder) value(t *introspection.Type, nonNull bool) (reflect.Value, error) {
+	var v reflect.Value
+	switch t.Kind() {
+	case "NON_NULL":
+		return b.value(t.OfType(), true)
+	case "LIST":
+		elem, err := b.value(t.OfType(), false)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v = reflect.Append(reflect.MakeSlice(reflect.SliceOf(elem.Type()), 0, 1), elem)
+	case "SCALAR":
+		v = scalarValue(*t.Name())
+	case "ENUM":
+		values := *t.EnumValues(&struct{ IncludeDeprecated bool }{true})
+		v = reflect.ValueOf(values[0].Name())
+	case "OBJECT":
+		// Objects are always resolved by pointers, which satisfy nullable fields as well.
+		return b.objectValue(*t.Name(), *t.Fields(&struct{ IncludeDeprecated bool }{true}))
+	default:
+		return reflect.Value{}, fmt.Errorf("stub resolvers do not support %s types such as %q", strings.ToLower(t.Kind()), *t.Name())
+	}
+
+	if nonNull {
+		return v, nil
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr, nil
+}
+
+func scalarValue(name string) reflect.Value {
+	switch name {
+	case "Int":
+		return reflect.ValueOf(int32(0))
+	case "Float":
+		return reflect.ValueOf(float64(0))
+	case "String":
+		return reflect.ValueOf("")
+	case "Boolean":
+		return reflect.ValueOf(false)
+	case "ID":
+		return reflect.ValueOf(graphql.ID(""))
+	default:
+		return reflect.ValueOf(stubScalar{})
+	}
+}
+
+// goFieldName returns an exported Go field name that graphql.UseFieldResolvers matches to the
+// GraphQL field name.
+func goFieldName(name string) string {
+	r := []rune(strings.Replace(name, "_", "", -1))
+	if len(r) == 0 {
+		return ""
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
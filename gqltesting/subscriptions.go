@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"strconv"
 	"testing"
+	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/errors"
@@ -17,7 +18,10 @@ type TestResponse struct {
 	Errors []*errors.QueryError
 }
 
-// TestSubscription is a GraphQL test case to be used with RunSubscribe.
+// TestSubscription is a GraphQL subscription test case to be used with RunSubscriptionTest(s).
+// ExpectedResults lists, in order, every payload the subscription is expected to deliver before
+// its channel closes. A payload expected with empty Data, such as one carrying only errors, must
+// have no data or null data.
 type TestSubscription struct {
 	Name            string
 	Schema          *graphql.Schema
@@ -25,10 +29,205 @@ type TestSubscription struct {
 	OperationName   string
 	Variables       map[string]interface{}
 	ExpectedResults []TestResponse
-	ExpectedErr     error
+
+	// ExpectedErr, if set, asserts that Schema.Subscribe itself fails with an error of this
+	// message, as it does for a schema without resolver or subscriptions, in which case no
+	// payload is delivered. Errors of the query, such as validation errors, are delivered as
+	// payloads instead and asserted through ExpectedResults.
+	ExpectedErr error
+
+	// Context is the context the subscription is made with. RunSubscriptionTest applies a default
+	// timeout if it carries no deadline.
+	Context context.Context
+
+	// ExpectedFinalError, if set, asserts that the subscription terminates with an error: after
+	// ExpectedResults, exactly one more payload carrying this error, compared by message and path,
+	// must be delivered before the channel closes. With no ExpectedResults, this tells a
+	// subscription failing on its first resolve apart from one closing without any payload.
+	ExpectedFinalError *errors.QueryError
+}
+
+// SubscriptionTest is an alias of TestSubscription.
+type SubscriptionTest = TestSubscription
+
+// subscriptionTimeout bounds how long RunSubscriptionTest waits on a subscription whose context
+// has no deadline.
+const subscriptionTimeout = 10 * time.Second
+
+// RunSubscriptionTests runs the given GraphQL subscription test cases as subtests.
+func RunSubscriptionTests(t *testing.T, tests []*SubscriptionTest) {
+	if len(tests) == 1 {
+		RunSubscriptionTest(t, tests[0])
+		return
+	}
+
+	for i, test := range tests {
+		test := test
+		t.Run(subtestName(test.Name, test.Query, i), func(t *testing.T) {
+			RunSubscriptionTest(t, test)
+		})
+	}
+}
+
+// RunSubscriptionTest runs a single GraphQL subscription test case. It drains the channel returned
+// by Schema.Subscribe, comparing each payload against ExpectedResults, and asserts that the channel
+// closes once all expected payloads have been received. If test.Context carries no deadline, a
+// default timeout is applied so that a hung subscription fails the test instead of blocking.
+func RunSubscriptionTest(t *testing.T, test *SubscriptionTest) {
+	t.Helper()
+	ctx := test.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, subscriptionTimeout)
+		defer cancel()
+	}
+
+	c, err := test.Schema.Subscribe(ctx, test.Query, test.OperationName, test.Variables)
+	switch {
+	case err != nil && test.ExpectedErr == nil:
+		t.Fatalf("unexpected error: %s", err)
+	case err != nil:
+		if err.Error() != test.ExpectedErr.Error() {
+			t.Errorf("got error %q, want %q", err, test.ExpectedErr)
+		}
+		return
+	case test.ExpectedErr != nil:
+		t.Fatalf("got no error, want %q", test.ExpectedErr)
+	}
+
+	for i, expected := range test.ExpectedResults {
+		var res interface{}
+		var ok bool
+		select {
+		case res, ok = <-c:
+		case <-ctx.Done():
+			t.Fatalf("payload %d: %s while waiting for subscription", i+1, ctx.Err())
+		}
+		if !ok {
+			t.Fatalf("subscription closed after %d payloads, want %d", i, len(test.ExpectedResults))
+		}
+
+		resp := res.(*graphql.Response)
+		checkErrors(t, expected.Errors, resp.Errors, false, false)
+		checkPayloadData(t, expected.Data, resp.Data)
+	}
+
+	n := len(test.ExpectedResults)
+	if want := test.ExpectedFinalError; want != nil {
+		var res interface{}
+		var ok bool
+		select {
+		case res, ok = <-c:
+		case <-ctx.Done():
+			t.Fatalf("%s while waiting for final error after %d payloads", ctx.Err(), n)
+		}
+		if !ok {
+			t.Fatalf("subscription closed after %d payloads without error, want final error %q", n, want.Message)
+		}
+		checkFinalError(t, want, res.(*graphql.Response).Errors)
+		n++
+	}
+
+	select {
+	case res, ok := <-c:
+		if ok {
+			t.Fatalf("unexpected payload after %d payloads: %+v", n, res)
+		}
+	case <-ctx.Done():
+		t.Fatalf("subscription not closed after %d payloads: %s", n, ctx.Err())
+	}
+}
+
+// RunSubscriptionCancellationTest asserts that a subscription shuts down cleanly when its context
+// is cancelled mid-stream. It receives the payloads of test.ExpectedResults, comparing them as
+// RunSubscriptionTest does, then cancels the context and asserts that the channel closes within
+// tolerance without delivering any further payload, and that the goroutines started since
+// subscribing, such as those of the resolver, have exited by then. A single payload may still
+// arrive, for the event the executor was resolving or delivering when the context was cancelled,
+// possibly carrying the cancellation error. test.ExpectedErr and test.ExpectedFinalError are
+// ignored. Since any
+// goroutine started meanwhile counts as leaked, the test should not run in parallel with others.
+func RunSubscriptionCancellationTest(t *testing.T, test *SubscriptionTest, tolerance time.Duration) {
+	t.Helper()
+	parent := test.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	before := goroutines()
+	c, err := test.Schema.Subscribe(ctx, test.Query, test.OperationName, test.Variables)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	timeout := time.After(subscriptionTimeout)
+	for i, expected := range test.ExpectedResults {
+		var res interface{}
+		var ok bool
+		select {
+		case res, ok = <-c:
+		case <-timeout:
+			t.Fatalf("payload %d: timed out waiting for subscription", i+1)
+		}
+		if !ok {
+			t.Fatalf("subscription closed after %d payloads, want %d before cancelling", i, len(test.ExpectedResults))
+		}
+
+		resp := res.(*graphql.Response)
+		checkErrors(t, expected.Errors, resp.Errors, false, false)
+		checkPayloadData(t, expected.Data, resp.Data)
+	}
+
+	cancel()
+	deadline := time.After(tolerance)
+	for received, closed := 0, false; !closed; {
+		select {
+		case res, ok := <-c:
+			if received++; ok && received > 1 {
+				t.Errorf("got payload %d after cancellation: %+v", received, res)
+			}
+			closed = !ok
+		case <-deadline:
+			t.Fatalf("subscription not closed %s after cancellation", tolerance)
+		}
+	}
+	checkLeaks(t, before, deadline)
+}
+
+// checkPayloadData compares the data of a subscription payload against the expected data. A
+// payload expected without data, such as one carrying only errors, must have no data or null data.
+func checkPayloadData(t testing.TB, expected, actual json.RawMessage) {
+	if len(expected) > 0 {
+		checkData(t, &comparison{}, expected, actual)
+		return
+	}
+	if len(actual) > 0 && !isJSONNull(actual) {
+		t.Fatalf("got: %s\nwant: no data", actual)
+	}
+}
+
+// checkFinalError verifies that got consists of the single error want, comparing messages and paths.
+func checkFinalError(t testing.TB, want *errors.QueryError, got []*errors.QueryError) {
+	if len(got) != 1 {
+		t.Fatalf("final payload: got %d errors %s, want final error %q", len(got), errorMessages(got), want.Message)
+	}
+	if got[0].Message != want.Message {
+		t.Errorf("final payload: got error %q, want %q", got[0].Message, want.Message)
+	}
+	if _, ok := firstPathDifference(want.Path, got[0].Path); !ok {
+		t.Errorf("final payload: got error path %v, want %v", got[0].Path, want.Path)
+	}
 }
 
 // RunSubscribes runs the given GraphQL subscription test cases as subtests.
+//
+// Deprecated: Use RunSubscriptionTests, which also asserts that each subscription closes after its
+// expected payloads and compares errors like RunTest.
 func RunSubscribes(t *testing.T, tests []*TestSubscription) {
 	for i, test := range tests {
 		if test.Name == "" {
@@ -42,6 +241,9 @@ func RunSubscribes(t *testing.T, tests []*TestSubscription) {
 }
 
 // RunSubscribe runs a single GraphQL subscription test case.
+//
+// Deprecated: Use RunSubscriptionTest, which also asserts that the subscription closes after the
+// expected payloads and compares errors like RunTest.
 func RunSubscribe(t *testing.T, test *TestSubscription) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -69,16 +271,16 @@ func RunSubscribe(t *testing.T, test *TestSubscription) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		got, err := formatJSON(resData)
+		got, err := FormatJSON(resData)
 		if err != nil {
-			t.Fatalf("got: invalid JSON: %s; raw: %s", err, resData)
+			t.Fatalf("got: %s", invalidJSON(err, resData))
 		}
 
 		expectedData, err := expected.Data.MarshalJSON()
 		if err != nil {
 			t.Fatal(err)
 		}
-		want, err := formatJSON(expectedData)
+		want, err := FormatJSON(expectedData)
 		if err != nil {
 			t.Fatalf("got: invalid JSON: %s; raw: %s", err, expectedData)
 		}
@@ -91,7 +293,7 @@ func RunSubscribe(t *testing.T, test *TestSubscription) {
 	}
 }
 
-func checkErrorStrings(t *testing.T, expected, actual []*errors.QueryError) {
+func checkErrorStrings(t testing.TB, expected, actual []*errors.QueryError) {
 	expectedCount, actualCount := len(expected), len(actual)
 
 	if expectedCount != actualCount {
@@ -0,0 +1,50 @@
+package gqltesting
+
+import "testing"
+
+// A Suite is a batch of tests sharing an expensive setup, such as a schema backed by a database.
+// It replaces the TestMain functions that suites otherwise write to set up and tear down their
+// fixtures. Before and After typically share state with the tests through closures, for example
+// assigning the schema of the tests.
+type Suite struct {
+	// Before, if set, is called once before the tests. If it fails the test, the tests are not run.
+	Before func(t *testing.T)
+
+	// After, if set, is called once after all the tests have completed, including the parallel
+	// ones. It is called whether or not the tests, or Before, failed, and even if they panicked.
+	After func(t *testing.T)
+
+	// Tests are the tests of the suite. Since they are only run after Before, it may fill in their
+	// Schema or other fields.
+	Tests []*Test
+}
+
+// Run runs the tests of the suite with RunTests, between Before and After. Parallel tests, see
+// WithParallel, are grouped in a subtest named "tests" so that After only runs once they finish.
+// Each of them runs in a subtest of its own, even if it is the only one, since t.Parallel must not
+// be called on "tests" itself.
+func (s *Suite) Run(t *testing.T, opts ...Option) {
+	t.Helper()
+	if s.After != nil {
+		defer s.After(t)
+	}
+	if s.Before != nil {
+		s.Before(t)
+		if t.Failed() {
+			return
+		}
+	}
+
+	if !newRunConfig(opts).parallel {
+		RunTests(t, s.Tests, opts...)
+		return
+	}
+	t.Run("tests", func(t *testing.T) {
+		for i, test := range s.Tests {
+			test := test
+			t.Run(subtestName(test.Name, test.Query, i), func(t *testing.T) {
+				RunTest(t, test, opts...)
+			})
+		}
+	})
+}
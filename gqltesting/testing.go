@@ -3,12 +3,17 @@ package gqltesting
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"reflect"
+	"io"
+	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/errors"
@@ -16,6 +21,7 @@ import (
 
 // Test is a GraphQL test case to be used with RunTest(s).
 type Test struct {
+	Name           string
 	Context        context.Context
 	Schema         *graphql.Schema
 	Query          string
@@ -23,82 +29,483 @@ type Test struct {
 	Variables      map[string]interface{}
 	ExpectedResult string
 	ExpectedErrors []*errors.QueryError
+
+	// ContextValues are added to the context the test is executed with, on top of Context.
+	ContextValues map[interface{}]interface{}
+
+	// VariablesJSON and VariablesFile provide the variables as a JSON object, inline or read from
+	// a file, as real clients send them. Only one of Variables, VariablesJSON and VariablesFile may
+	// be set.
+	//
+	// Values of Variables that are Go structs, pointers to structs or lists of them, such as the
+	// input types of production code, are marshaled to JSON and decoded back into generic values
+	// before execution, as if a client had sent them. Their fields are named following their json
+	// tags.
+	VariablesJSON string
+	VariablesFile string
+
+	// ExpectNullData asserts that the response data is present and exactly JSON null, as happens
+	// when an error propagates to the root. In contrast, an empty ExpectedResult asserts that the
+	// response carries no data at all. It must not be combined with ExpectedResult.
+	ExpectNullData bool
+
+	// ExpectPartial asserts that the data is partial in a way consistent with the errors: for each
+	// expected error with a path (or each returned error, if ExpectedErrors is empty), the data at
+	// that path, or at an ancestor the null propagated to, must be null. This catches resolvers that
+	// report an error but still return a non-null stub value.
+	ExpectPartial bool
+
+	// ExpectNoErrors asserts that the operation returns no errors, listing any that do occur. It
+	// must not be combined with ExpectedErrors.
+	ExpectNoErrors bool
+
+	// ExpectedErrorCount, if not nil, asserts the number of returned errors, listing them if it
+	// differs. It may be combined with the other expectations of the errors; on its own, it is the
+	// only assertion made about the errors.
+	ExpectedErrorCount *int
+
+	// ExpectedResultBySchema overrides ExpectedResult for the schema of the given name when the
+	// test is run with RunTestMatrix.
+	ExpectedResultBySchema map[string]string
+
+	// ExpectedExtensions is compared against the JSON encoding of the response's top-level
+	// extensions, if set. Note that Schema.Exec does not currently populate extensions, so for now
+	// this can only assert that none are returned.
+	ExpectedExtensions string
+
+	// GoldenFile is the path of a file holding the expected result. It is used when ExpectedResult
+	// is empty, and is rewritten with the actual result when running go test -update or with
+	// UpdateEnv set.
+	GoldenFile string
+
+	// FixtureFile is the path of a file holding the whole expected response, such as a captured
+	// one, as a JSON object with the expected data under "data" and the expected errors, if any,
+	// under "errors". The errors are compared as JSON, after sorting by path, and placeholders are
+	// supported in both. It is rewritten with the actual response like GoldenFile, and must not be
+	// combined with the other expectations of the data or the errors.
+	FixtureFile string
+
+	// IgnoreFields lists paths of fields, such as "data.users[].id", that are removed from both the
+	// expected and actual results before they are compared.
+	IgnoreFields []string
+
+	// FieldMatchers maps paths of fields to predicates their values must satisfy. Matched fields
+	// are then removed from both results, like IgnoreFields, before the rest is compared literally.
+	FieldMatchers map[string]FieldMatcher
+
+	// MaxResolverCalls limits how often the resolver of each field, keyed by "Type.field", may be
+	// invoked. It requires the schema to be built with a tracer wrapped by Instrument.
+	MaxResolverCalls map[string]int
+
+	// ExpectedResolutionOrder asserts the order in which the resolvers of the given fields, keyed
+	// by "Type.field", are invoked, such as the top-level fields of a mutation, which the spec
+	// requires to execute serially in document order. Fields not listed are not considered, and a
+	// field listed several times, such as under different aliases, must be invoked that many
+	// times. It requires the schema to be built with a tracer wrapped by Instrument.
+	ExpectedResolutionOrder []string
+
+	// MaxFieldDuration limits how long each invocation of the resolver of a field, keyed by
+	// "Type.field", may take. It requires the schema to be built with a tracer wrapped by
+	// Instrument, and is most reliable in combination with WithClock.
+	MaxFieldDuration map[string]time.Duration
+
+	// MaxResponseBytes, if positive, is the budget of the size of the data as serialized by the
+	// executor. Exceeding it fails the test, reporting the largest top-level field.
+	MaxResponseBytes int
+
+	// ExpectedErrorsContain lists substrings that must each appear in the message of at least one
+	// returned error. ExpectedErrorRegexps does the same for regular expressions. When either is set,
+	// ExpectedErrors is only compared by Path and Extensions, not by message.
+	ExpectedErrorsContain []string
+	ExpectedErrorRegexps  []*regexp.Regexp
+
+	// ExpectedErrorsSubset lists errors that must each be among the returned errors, which may hold
+	// more. An expected error matches a returned one with the same Message and Path, and the same
+	// Locations, Extensions and Rule where the expected error sets them. Each returned error is
+	// matched at most once. It suits tests focused on one failure among many independent fields,
+	// and cannot be combined with ExpectedErrors.
+	ExpectedErrorsSubset []*errors.QueryError
+
+	// ErrorMatcher, if set, replaces all other comparisons of the returned errors.
+	ErrorMatcher ErrorMatcher
+
+	// ExpectedErrorExtensions is compared key by key against the Extensions of each returned error,
+	// matched positionally after the errors are sorted by path.
+	ExpectedErrorExtensions []map[string]interface{}
+
+	// ExpectedLimitError asserts that the query is rejected by the query limit with the given
+	// validation rule, such as MaxDepthExceeded, instead of comparing the errors against
+	// ExpectedErrors. It is meant to test the limits protecting a schema from expensive queries.
+	ExpectedLimitError string
+
+	// ExpectedCallLog, if not nil, asserts the exact sequence of entries added to the CallLog of
+	// the test, such as one entry per batch of a dataloader. An empty, non-nil slice asserts that
+	// nothing was logged. With WithConcurrency or WithRetry, the log spans all executions. Since
+	// sibling fields of queries resolve concurrently, only calls ordered by the resolvers
+	// themselves, such as the batches of a loader, or made by the fields of a mutation, which
+	// execute serially, are logged in a deterministic order.
+	ExpectedCallLog []string
+
+	// ResponseJSONSchema, if set, is a JSON Schema the data must conform to, for fields whose
+	// exact values are loosely specified. Every violation is reported with the path of the
+	// offending value. ExpectedResult may then be left empty. The supported keywords are type,
+	// enum, const, properties, required, additionalProperties, minProperties, maxProperties,
+	// items (with a single schema), minItems, maxItems, uniqueItems, minLength, maxLength, pattern,
+	// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, anyOf and oneOf, along with
+	// annotations such as title; the test fails if the schema uses any other keyword, such as
+	// $ref.
+	ResponseJSONSchema string
+
+	// ExpectedResultValue, if not nil, is marshaled to JSON and compared like ExpectedResult, so
+	// that the expected data can be written as typed Go structs or maps, named following their
+	// json tags. It cannot be combined with ExpectedResult.
+	ExpectedResultValue interface{}
+
+	// ExpectedResponse is compared by RunTestHTTP against the complete response envelope, as the
+	// relay handler serializes it.
+	ExpectedResponse string
 }
 
 // RunTests runs the given GraphQL test cases as subtests.
-func RunTests(t *testing.T, tests []*Test) {
+func RunTests(t *testing.T, tests []*Test, opts ...Option) {
 	if len(tests) == 1 {
-		RunTest(t, tests[0])
+		RunTest(t, tests[0], opts...)
 		return
 	}
 
 	for i, test := range tests {
-		t.Run(strconv.Itoa(i+1), func(t *testing.T) {
-			RunTest(t, test)
+		test := test
+		t.Run(subtestName(test.Name, test.Query, i), func(t *testing.T) {
+			RunTest(t, test, opts...)
 		})
 	}
 }
 
-// RunTest runs a single GraphQL test case.
-func RunTest(t *testing.T, test *Test) {
-	if test.Context == nil {
-		test.Context = context.Background()
+// subtestName returns the name of the i-th subtest. Unnamed tests are named after their 1-based
+// index and a short hash of their query, so that a failure still points to the exact case when
+// the table is reordered, for example by go test -shuffle. Spaces and slashes are replaced so that
+// the name can be used with go test -run.
+func subtestName(name, query string, i int) string {
+	if name == "" {
+		name = strconv.Itoa(i + 1)
+		if query != "" {
+			sum := sha256.Sum256([]byte(query))
+			name += "_" + hex.EncodeToString(sum[:4])
+		}
+		return name
+	}
+	return strings.NewReplacer(" ", "_", "/", "_").Replace(name)
+}
+
+// RunTestWithTrace runs a single GraphQL test case like RunTest, and returns the timing of the
+// resolvers it invoked along with whether the test passed. It requires the schema to be built with
+// a tracer wrapped by Instrument.
+func RunTestWithTrace(t *testing.T, test *Test, opts ...Option) (*Trace, bool) {
+	tr := &Trace{}
+	RunTest(t, test, append(opts, withTrace(tr))...)
+	return tr, !t.Failed()
+}
+
+// RunTest runs a single GraphQL test case. Its failure messages are prefixed with the name of the
+// test, if any, and the operation name or, lacking one, the start of the query.
+//
+// The query is validated by Schema.Exec, with the rules of the GraphQL specification and the ones
+// the schema was configured with, such as the limit set by graphql.MaxDepth, so the errors of
+// these rules are asserted through ExpectedErrors like any other, reporting the rule in Rule. The
+// graphql package does not support custom validation rules: a rule a server enforces on top of
+// Schema.Exec, such as rejecting anonymous operations, is not exercised by RunTest and has to be
+// tested through the server itself.
+func RunTest(t *testing.T, test *Test, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	if cfg.parallel {
+		t.Parallel()
+	}
+	if cfg.httpRoundTrip {
+		runTestBothWays(t, test, cfg)
+		return
+	}
+	runTest(t, test, cfg)
+}
+
+func runTest(tb testing.TB, test *Test, cfg *runConfig) {
+	tb.Helper()
+	if cfg.quiet {
+		tb = &quietTB{TB: tb}
+	}
+	if cfg.leakCheck {
+		before := goroutines()
+		defer func() { checkLeaks(tb, before, time.After(leakGracePeriod)) }()
+	}
+	t := withFailurePrefix(tb, test)
+	if test.ExpectNoErrors && len(test.ExpectedErrors) > 0 {
+		t.Fatal("ExpectNoErrors and ExpectedErrors are mutually exclusive")
+	}
+	if test.ExpectNullData && test.ExpectedResult != "" {
+		t.Fatal("ExpectNullData and ExpectedResult are mutually exclusive")
+	}
+	if test.ExpectedResultValue != nil && (test.ExpectedResult != "" || test.ExpectNullData) {
+		t.Fatal("ExpectedResultValue is mutually exclusive with ExpectedResult and ExpectNullData")
+	}
+
+	if test.FixtureFile != "" && (test.ExpectedResult != "" || test.ExpectedResultValue != nil || test.ExpectNullData || test.GoldenFile != "" || test.ExpectedErrors != nil) {
+		t.Fatal("FixtureFile is mutually exclusive with ExpectedResult, ExpectedResultValue, ExpectNullData, GoldenFile and ExpectedErrors")
+	}
+
+	variables := testVariables(t, test)
+	if cfg.strict {
+		checkValid(t, test.Schema, test.Query, variables)
+	}
+
+	parent := testContext(test, cfg)
+	timeout := testTimeout(parent, cfg)
+	ctx, cancel := timeoutContext(parent, timeout)
+	defer cancel()
+	ctx, panics := withPanicRecorder(ctx)
+	var calls *resolverCalls
+	if len(test.MaxResolverCalls) > 0 || len(test.ExpectedResolutionOrder) > 0 {
+		ctx, calls = withResolverCalls(ctx)
+	}
+	var callLog *CallLog
+	if test.ExpectedCallLog != nil {
+		ctx, callLog = withCallLog(ctx)
+	}
+	if cfg.logging {
+		var logger *testLogger
+		ctx, logger = withTestLogger(ctx, tb)
+		defer logger.close()
+	}
+	var tr *traceRecorder
+	if cfg.trace != nil || len(test.MaxFieldDuration) > 0 {
+		if cfg.trace == nil {
+			cfg.trace = &Trace{}
+		}
+		ctx, tr = withTraceRecorder(ctx, cfg.trace, cfg.now)
+	}
+	results := execWithDeadline(t, ctx, parent, timeout, cfg.execFunc(), test, variables, cfg.concurrency)
+	result := results[0]
+	if cfg.dump {
+		defer dumpOnFailure(t, cfg.dumpWriter, result)
+	}
+	var expected []byte
+	if cfg.jsonOutput {
+		defer func() { logJSONFailure(tb, test, cfg, expected, result) }()
+	}
+
+	panics.check(t, result.Errors)
+	if len(test.MaxResolverCalls) > 0 {
+		calls.check(t, test.MaxResolverCalls)
+	}
+	if len(test.ExpectedResolutionOrder) > 0 {
+		calls.checkOrder(t, test.ExpectedResolutionOrder)
+	}
+	if callLog != nil {
+		callLog.check(t, test.ExpectedCallLog)
+	}
+	if tr != nil {
+		tr.finish()
+		tr.check(t, test.MaxFieldDuration)
+	}
+
+	var fixtureData []byte
+	if test.FixtureFile != "" {
+		var fixtureErrors []*errors.QueryError
+		fixtureData, fixtureErrors = fixtureResult(t, test.FixtureFile, result)
+		checkFixtureErrors(t, cfg, fixtureErrors, result.Errors)
+	} else {
+		checkTestErrors(t, test, cfg, result.Errors)
+	}
+
+	if test.MaxResponseBytes > 0 {
+		checkResponseSize(t, test.MaxResponseBytes, result.Data)
+	}
+
+	if test.ExpectedExtensions != "" {
+		checkExtensions(t, []byte(test.ExpectedExtensions), result.Extensions)
 	}
-	result := test.Schema.Exec(test.Context, test.Query, test.OperationName, test.Variables)
 
-	checkErrors(t, test.ExpectedErrors, result.Errors)
+	expected = []byte(test.ExpectedResult)
+	if test.ExpectedResultValue != nil {
+		expected = marshalExpected(t, test.ExpectedResultValue)
+	}
+	if test.ExpectedResult == "" && test.GoldenFile != "" {
+		expected = goldenResult(t, test.GoldenFile, result.Data)
+	}
+	if test.FixtureFile != "" {
+		expected = fixtureData
+	}
+
+	if len(test.FieldMatchers) > 0 {
+		checkFieldMatchers(t, test.FieldMatchers, result.Data)
+	}
+
+	if test.ExpectPartial {
+		errs := test.ExpectedErrors
+		if len(errs) == 0 {
+			errs = result.Errors
+		}
+		checkPartial(t, errs, result.Data)
+	}
+
+	if len(results) > 1 {
+		checkIterations(t, testComparison(test, cfg), expected, results)
+	}
 
-	if test.ExpectedResult == "" {
-		if result.Data != nil {
-			t.Fatalf("got: %s", result.Data)
-			t.Fatalf("want: null")
+	if test.ResponseJSONSchema != "" {
+		checkJSONSchema(t, test.ResponseJSONSchema, result.Data)
+		if len(expected) == 0 {
+			return
+		}
+	}
+
+	if test.ExpectNullData {
+		switch {
+		case result.Data == nil:
+			t.Fatal("got: no data\nwant: null data")
+		case !isJSONNull(result.Data):
+			t.Fatalf("got: %s\nwant: null data", result.Data)
+		}
+		return
+	}
+
+	if len(expected) == 0 {
+		switch {
+		case result.Data == nil:
+		case isJSONNull(result.Data):
+			t.Fatal("got: null data\nwant: no data (set ExpectNullData to expect null data)")
+		default:
+			t.Fatalf("got: %s\nwant: null", result.Data)
 		}
 		return
 	}
 
-	// Verify JSON to avoid red herring errors.
-	got, err := formatJSON(result.Data)
+	if cfg.exactShape {
+		checkShape(t, testComparison(test, cfg), expected, result.Data)
+	}
+	checkData(t, testComparison(test, cfg), expected, result.Data)
+}
+
+// testContext returns the context to execute test with. See WithContext for the precedence rules.
+func testContext(test *Test, cfg *runConfig) context.Context {
+	ctx := test.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if cfg.ctx != nil {
+		ctx = cfg.ctx
+	}
+	for k, v := range test.ContextValues {
+		ctx = context.WithValue(ctx, k, v)
+	}
+	for _, kv := range cfg.contextValues {
+		ctx = context.WithValue(ctx, kv[0], kv[1])
+	}
+	return ctx
+}
+
+// testComparison returns how the results of test are compared, applying normalizers in order.
+func testComparison(test *Test, cfg *runConfig) *comparison {
+	c := &comparison{useNumber: cfg.useNumber, floatTolerance: cfg.floatTolerance, noColor: cfg.noColor, placeholder: cfg.placeholder, base64Paths: cfg.base64Paths}
+	if len(test.IgnoreFields) > 0 {
+		c.normalizers = append(c.normalizers, ignoreFields(test.IgnoreFields))
+	}
+	if len(test.FieldMatchers) > 0 {
+		c.normalizers = append(c.normalizers, ignoreFields(sortedMatcherPaths(test.FieldMatchers)))
+	}
+	c.normalizers = append(c.normalizers, cfg.normalizers...)
+	return c
+}
+
+// marshalExpected returns the expected data given as the Go value v in canonical JSON.
+func marshalExpected(t testing.TB, v interface{}) []byte {
+	data, err := json.Marshal(v)
 	if err != nil {
-		t.Fatalf("got: invalid JSON: %s", err)
+		t.Fatalf("ExpectedResultValue: %s", err)
 	}
-	want, err := formatJSON([]byte(test.ExpectedResult))
+	formatted, err := FormatJSON(data)
 	if err != nil {
-		t.Fatalf("want: invalid JSON: %s", err)
+		t.Fatalf("ExpectedResultValue: %s", err)
 	}
+	return formatted
+}
 
-	if !bytes.Equal(got, want) {
-		t.Logf("got:  %s", got)
-		t.Logf("want: %s", want)
+// isJSONNull reports whether data is the JSON literal null.
+func isJSONNull(data []byte) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
+
+// checkData compares the JSON-normalized expected and actual data, logging a diff on mismatch.
+func checkData(t testing.TB, c *comparison, expected, actual []byte) {
+	diff, ok, err := c.compare(expected, actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Logf("diff:\n%s", diff)
 		t.Fail()
 	}
 }
 
-func formatJSON(data []byte) ([]byte, error) {
-	var v interface{}
-	if err := json.Unmarshal(data, &v); err != nil {
-		return nil, err
+// checkResponseSize fails the test if data is larger than max bytes, identifying the top-level
+// field contributing the most to it.
+func checkResponseSize(t testing.TB, max int, data []byte) {
+	if len(data) <= max {
+		return
 	}
-	formatted, err := json.Marshal(v)
-	if err != nil {
-		return nil, err
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || len(fields) == 0 {
+		t.Errorf("data is %d bytes, want at most %d", len(data), max)
+		return
 	}
-	return formatted, nil
+	var largest string
+	for _, name := range sortedRawKeys(fields) {
+		if len(fields[name]) > len(fields[largest]) {
+			largest = name
+		}
+	}
+	t.Errorf("data is %d bytes, want at most %d; the largest field is %q with %d bytes", len(data), max, largest, len(fields[largest]))
 }
 
-func checkErrors(t *testing.T, want, got []*errors.QueryError) {
-	sortErrors(want)
-	sortErrors(got)
+func sortedRawKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("unexpected error: got %+v, want %+v", got, want)
+// checkExtensions compares the expected JSON against the response's top-level extensions.
+func checkExtensions(t testing.TB, expected []byte, extensions map[string]interface{}) {
+	actual, err := json.Marshal(extensions)
+	if err != nil {
+		t.Fatalf("extensions: %s", err)
+	}
+	diff, ok, err := (&comparison{}).compare(expected, actual)
+	if err != nil {
+		t.Fatalf("extensions: %s", err)
+	}
+	if !ok {
+		t.Logf("extensions diff:\n%s", diff)
+		t.Fail()
 	}
 }
 
-func sortErrors(errors []*errors.QueryError) {
-	if len(errors) <= 1 {
+// dumpOnFailure writes the pretty-printed response to w, or the test log if w is nil, if the test
+// has failed. It is meant to be deferred so that it also runs after t.Fatal.
+func dumpOnFailure(t testing.TB, w io.Writer, result *graphql.Response) {
+	if !t.Failed() {
+		return
+	}
+	resp, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		t.Logf("dumping response: %s", err)
+		return
+	}
+	if w == nil {
+		t.Logf("response:\n%s", resp)
 		return
 	}
-	sort.Slice(errors, func(i, j int) bool {
-		return fmt.Sprintf("%s", errors[i].Path) < fmt.Sprintf("%s", errors[j].Path)
-	})
+	fmt.Fprintf(w, "response:\n%s\n", resp)
 }
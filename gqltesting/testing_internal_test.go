@@ -0,0 +1,876 @@
+package gqltesting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// fakeTB records the output of a test helper instead of failing the enclosing test.
+type fakeTB struct {
+	testing.TB
+
+	mu     sync.Mutex
+	output []string
+	failed bool
+}
+
+// runFake runs fn against a fakeTB in its own goroutine, so that Fatal can stop it without
+// affecting the caller, and returns the recorded state.
+func runFake(fn func(tb testing.TB)) *fakeTB {
+	tb := &fakeTB{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(tb)
+	}()
+	<-done
+	return tb
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Name() string {
+	return "TestFake"
+}
+
+func (tb *fakeTB) Log(args ...interface{}) {
+	tb.record(fmt.Sprint(args...))
+}
+
+func (tb *fakeTB) Logf(format string, args ...interface{}) {
+	tb.record(fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Error(args ...interface{}) {
+	tb.Log(args...)
+	tb.Fail()
+}
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.Logf(format, args...)
+	tb.Fail()
+}
+
+func (tb *fakeTB) Fatal(args ...interface{}) {
+	tb.Error(args...)
+	runtime.Goexit()
+}
+
+func (tb *fakeTB) Fatalf(format string, args ...interface{}) {
+	tb.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (tb *fakeTB) Fail() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.failed = true
+}
+
+func (tb *fakeTB) Failed() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.failed
+}
+
+func (tb *fakeTB) record(s string) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.output = append(tb.output, s)
+}
+
+func (tb *fakeTB) String() string {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return strings.Join(tb.output, "\n")
+}
+
+type helloResolver struct{}
+
+func (r *helloResolver) Hello() string {
+	return "Hello world!"
+}
+
+func (r *helloResolver) Slow(ctx context.Context) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+var helloSchema = graphql.MustParseSchema(`
+	type Query {
+		hello: String!
+		slow: String!
+	}
+`, &helloResolver{})
+
+func TestRunTest_unexpectedData(t *testing.T) {
+	tb := runFake(func(tb testing.TB) {
+		runTest(tb, &Test{
+			Schema: helloSchema,
+			Query:  `{ hello }`,
+		}, &runConfig{})
+	})
+
+	if !tb.Failed() {
+		t.Fatal("test did not fail")
+	}
+	out := tb.String()
+	for _, want := range []string{`got: {"hello":"Hello world!"}`, "want: null"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+}
+
+func TestRunTest_dumpOnFailure(t *testing.T) {
+	var buf strings.Builder
+	tb := runFake(func(tb testing.TB) {
+		runTest(tb, &Test{
+			Schema:         helloSchema,
+			Query:          `{ hello }`,
+			ExpectedErrors: []*errors.QueryError{{Message: "expected"}},
+		}, newRunConfig([]Option{WithDumpOnFailure(&buf)}))
+	})
+
+	if !tb.Failed() {
+		t.Fatal("test did not fail")
+	}
+	if want := `"hello": "Hello world!"`; !strings.Contains(buf.String(), want) {
+		t.Errorf("dump %q does not contain %q", buf.String(), want)
+	}
+}
+
+func TestRunTest_deadlineExceeded(t *testing.T) {
+	tb := runFake(func(tb testing.TB) {
+		runTest(tb, &Test{
+			Schema:         helloSchema,
+			Query:          `{ slow }`,
+			ExpectedResult: `{"slow": "done"}`,
+		}, newRunConfig([]Option{WithTimeout(10 * time.Millisecond)}))
+	})
+
+	if !tb.Failed() {
+		t.Fatal("test did not fail")
+	}
+	out := tb.String()
+	for _, want := range []string{"query exceeded 10ms; likely a blocking resolver", "query: { slow }"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+}
+
+type counterResolver struct {
+	calls int32
+}
+
+func (r *counterResolver) Count() int32 {
+	return atomic.AddInt32(&r.calls, 1)
+}
+
+func TestRunTest_concurrencyDiverged(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			count: Int!
+		}
+	`, &counterResolver{})
+
+	tb := runFake(func(tb testing.TB) {
+		runTest(tb, &Test{
+			Schema:         schema,
+			Query:          `{ count }`,
+			ExpectedResult: `{"count": 1}`,
+		}, newRunConfig([]Option{WithConcurrency(3)}))
+	})
+
+	if !tb.Failed() {
+		t.Fatal("test did not fail")
+	}
+	if want := "of 3 diverged"; !strings.Contains(tb.String(), want) {
+		t.Errorf("output %q does not contain %q", tb.String(), want)
+	}
+}
+
+// flakyResolver times out on the first failures calls.
+type flakyResolver struct {
+	failures int32
+	calls    int32
+}
+
+func (r *flakyResolver) Count() (int32, error) {
+	n := atomic.AddInt32(&r.calls, 1)
+	if n <= r.failures {
+		return 0, fmt.Errorf("upstream timeout")
+	}
+	return n, nil
+}
+
+func TestRunTest_retry(t *testing.T) {
+	transient := func(err *errors.QueryError) bool {
+		return strings.Contains(err.Message, "timeout")
+	}
+	tests := []struct {
+		name     string
+		failures int32
+		expected string
+		fail     bool
+		calls    int32
+	}{
+		{name: "recovers", failures: 2, expected: `{"count": 3}`, calls: 3},
+		{name: "gives up", failures: 5, expected: `{"count": 4}`, fail: true, calls: 4},
+		{name: "wrong data is not retried", expected: `{"count": 2}`, fail: true, calls: 1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := &flakyResolver{failures: tt.failures}
+			schema := graphql.MustParseSchema(`
+				type Query {
+					count: Int!
+				}
+			`, r)
+
+			tb := runFake(func(tb testing.TB) {
+				runTest(tb, &Test{
+					Schema:         schema,
+					Query:          `{ count }`,
+					ExpectedResult: tt.expected,
+				}, newRunConfig([]Option{WithRetry(3, time.Millisecond, transient)}))
+			})
+
+			if tb.Failed() != tt.fail {
+				t.Errorf("got failed %v, want %v; output:\n%s", tb.Failed(), tt.fail, tb.String())
+			}
+			if r.calls != tt.calls {
+				t.Errorf("got %d calls, want %d", r.calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestRunTest_jsonOutput(t *testing.T) {
+	tb := runFake(func(tb testing.TB) {
+		runTest(tb, &Test{
+			Schema: helloSchema,
+			Query:  `{ hello }`,
+			ExpectedResult: `{
+				"hello": "Goodbye!"
+			}`,
+		}, newRunConfig([]Option{WithJSONOutput()}))
+	})
+
+	if !tb.Failed() {
+		t.Fatal("test did not fail")
+	}
+	last := tb.output[len(tb.output)-1]
+	if strings.Contains(last, "\n") {
+		t.Errorf("output %q is not a single line", last)
+	}
+	var f struct {
+		Test     string
+		Query    string
+		Expected map[string]interface{}
+		Got      map[string]interface{}
+		Diff     string
+	}
+	if err := json.Unmarshal([]byte(last), &f); err != nil {
+		t.Fatalf("output %q is not JSON: %s", last, err)
+	}
+	if f.Test != "TestFake" || f.Query != `{ hello }` || f.Expected["hello"] != "Goodbye!" || f.Got["hello"] != "Hello world!" || f.Diff == "" {
+		t.Errorf("unexpected failure description %+v", f)
+	}
+}
+
+func TestRunTest_failurePrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		test   *Test
+		prefix string
+	}{
+		{
+			name:   "query",
+			test:   &Test{Query: "{\n\thello\n}"},
+			prefix: `query "{ hello }": `,
+		},
+		{
+			name:   "named operation",
+			test:   &Test{Name: "greets", Query: `query Greet { hello }`, OperationName: "Greet"},
+			prefix: "greets, operation Greet: ",
+		},
+		{
+			name:   "long query",
+			test:   &Test{Query: "{ hello " + strings.Repeat("hello ", 20) + "}"},
+			prefix: `query "{ hello hello`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test.Schema = helloSchema
+			tt.test.ExpectedResult = `{"hello": "Goodbye!"}`
+			tb := runFake(func(tb testing.TB) {
+				runTest(tb, tt.test, newRunConfig(nil))
+			})
+
+			if !tb.Failed() || len(tb.output) == 0 {
+				t.Fatal("test did not fail")
+			}
+			for _, out := range tb.output {
+				if !strings.HasPrefix(out, tt.prefix) {
+					t.Errorf("output %q does not start with %q", out, tt.prefix)
+				}
+			}
+		})
+	}
+}
+
+type panicResolver struct{}
+
+func (r *panicResolver) Boom() string {
+	panic("boom")
+}
+
+func TestRunTest_capturePanics(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			boom: String!
+		}
+	`, &panicResolver{}, graphql.Logger(CapturePanics(nil)))
+
+	tb := runFake(func(tb testing.TB) {
+		runTest(tb, &Test{
+			Schema:         schema,
+			Query:          `{ boom }`,
+			ExpectedResult: `{"boom": "unreachable"}`,
+		}, &runConfig{})
+	})
+
+	if !tb.Failed() {
+		t.Fatal("test did not fail")
+	}
+	out := tb.String()
+	for _, want := range []string{"1 resolver panic(s):", "panic: boom (path: [boom])\n", "(*panicResolver).Boom"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+}
+
+func TestFirstNamedDifference(t *testing.T) {
+	a := normalizedSchema(t, graphql.MustParseSchema(`
+		type Query {
+			user: User
+		}
+
+		type User {
+			id: ID!
+			name: String!
+		}
+	`, nil))
+	b := normalizedSchema(t, graphql.MustParseSchema(`
+		type Query {
+			user: User
+		}
+
+		type User {
+			id: ID!
+			name: String
+		}
+	`, nil))
+
+	diff := firstNamedDifference(a["types"], b["types"], "type", nil)
+	if want := "field User.name differs"; !strings.HasPrefix(diff, want) {
+		t.Errorf("got %q, want prefix %q", diff, want)
+	}
+}
+
+type loggingResolver struct{}
+
+func (r *loggingResolver) Hello(ctx context.Context) string {
+	ResolverLoggerFromContext(ctx).Logf("resolving %s", "hello")
+	return "Hello!"
+}
+
+func TestRunTest_resolverLogging(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+	`, &loggingResolver{})
+
+	for _, logging := range []bool{false, true} {
+		var opts []Option
+		if logging {
+			opts = append(opts, WithResolverLogging())
+		}
+		tb := runFake(func(tb testing.TB) {
+			runTest(tb, &Test{
+				Schema:         schema,
+				Query:          `{ hello }`,
+				ExpectedResult: `{"hello": "Hello!"}`,
+			}, newRunConfig(opts))
+		})
+
+		if tb.Failed() {
+			t.Fatalf("test failed:\n%s", tb.String())
+		}
+		if got := strings.Contains(tb.String(), "resolving hello"); got != logging {
+			t.Errorf("with logging %v: got output %q", logging, tb.String())
+		}
+	}
+}
+
+func TestRunTest_quiet(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+	`, &loggingResolver{})
+
+	for _, expected := range []string{`{"hello": "Hello!"}`, `{"hello": "Goodbye!"}`} {
+		tb := runFake(func(tb testing.TB) {
+			runTest(tb, &Test{
+				Schema:         schema,
+				Query:          `{ hello }`,
+				ExpectedResult: expected,
+			}, newRunConfig([]Option{WithResolverLogging(), WithQuiet()}))
+		})
+
+		switch {
+		case !tb.Failed() && len(tb.output) > 0:
+			t.Errorf("passing test logged %q", tb.String())
+		case tb.Failed() && (len(tb.output) < 2 || tb.output[0] != "resolving hello" || !strings.Contains(tb.output[1], "diff:")):
+			t.Errorf("failing test logged %q, want the held back output followed by the diff", tb.String())
+		}
+	}
+}
+
+type leakingResolver struct {
+	release chan struct{}
+}
+
+func (r *leakingResolver) Hello() string {
+	if r.release != nil {
+		go func() { <-r.release }()
+	}
+	return "Hello!"
+}
+
+func TestRunTest_leakCheck(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	for _, r := range []*leakingResolver{{}, {release: release}} {
+		schema := graphql.MustParseSchema(`
+			type Query {
+				hello: String!
+			}
+		`, r)
+		tb := runFake(func(tb testing.TB) {
+			runTest(tb, &Test{
+				Schema:         schema,
+				Query:          `{ hello }`,
+				ExpectedResult: `{"hello": "Hello!"}`,
+			}, newRunConfig([]Option{WithLeakCheck()}))
+		})
+
+		leaking := r.release != nil
+		switch {
+		case !leaking && tb.Failed():
+			t.Errorf("test without leak failed: %s", tb.String())
+		case leaking && !tb.Failed():
+			t.Error("test leaking a goroutine passed")
+		case leaking && !strings.Contains(tb.String(), "leakingResolver"):
+			t.Errorf("leak report %q does not include the stack of the leaked goroutine", tb.String())
+		}
+	}
+}
+
+func TestSourceLine(t *testing.T) {
+	query := "{\n\tuser(id: 1) {\n\t\tnmae\n\t}\n}"
+	if got, want := sourceLine(query, 3, 3), "   3 | \t\tnmae\n     | \t\t^"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := sourceLine(query, 9, 1), "(the query has 5 lines)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCheckResponseSize(t *testing.T) {
+	data := []byte(`{"a":"x","b":"` + strings.Repeat("y", 100) + `","c":[1,2,3]}`)
+
+	if tb := runFake(func(tb testing.TB) { checkResponseSize(tb, len(data), data) }); tb.Failed() {
+		t.Errorf("data within the budget failed:\n%s", tb.String())
+	}
+	tb := runFake(func(tb testing.TB) { checkResponseSize(tb, 50, data) })
+	if want := `data is 128 bytes, want at most 50; the largest field is "b" with 102 bytes`; tb.String() != want {
+		t.Errorf("got output %q, want %q", tb.String(), want)
+	}
+}
+
+func TestRunTest_expectedErrorCount(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+	`, &loggingResolver{})
+
+	for _, tt := range []struct {
+		name     string
+		count    int
+		expected []*errors.QueryError
+		fail     bool
+	}{
+		{name: "matching count", count: 2},
+		{name: "differing count", count: 1, fail: true},
+		{name: "with detailed errors", count: 2, expected: []*errors.QueryError{
+			{Message: `Cannot query field "a" on type "Query".`, Locations: []errors.Location{{Line: 1, Column: 3}}, Rule: "FieldsOnCorrectType"},
+			{Message: `Cannot query field "b" on type "Query".`, Locations: []errors.Location{{Line: 1, Column: 5}}, Rule: "FieldsOnCorrectType"},
+		}},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := runFake(func(tb testing.TB) {
+				runTest(tb, &Test{
+					Schema:             schema,
+					Query:              `{ a b }`,
+					ExpectedErrors:     tt.expected,
+					ExpectedErrorCount: &tt.count,
+				}, newRunConfig(nil))
+			})
+
+			switch {
+			case tb.Failed() != tt.fail:
+				t.Errorf("failed = %v, want %v: %s", tb.Failed(), tt.fail, tb.String())
+			case tt.fail && !strings.Contains(tb.String(), "got 2 errors, want 1:\n\tCannot query field \"a\""):
+				t.Errorf("got %q, want the count along with the errors", tb.String())
+			}
+		})
+	}
+}
+
+func TestRunTest_strictValidationRejectsInvalidQuery(t *testing.T) {
+	r := &counterResolver{}
+	schema := graphql.MustParseSchema(`
+		type Query {
+			count: Int!
+		}
+	`, r)
+
+	tb := runFake(func(tb testing.TB) {
+		runTest(tb, &Test{
+			Schema:         schema,
+			Query:          `{ count goodbye }`,
+			ExpectedResult: `{"count": 1}`,
+		}, newRunConfig([]Option{WithStrictValidation()}))
+	})
+
+	const want = "query failed validation:\n\tgraphql: Cannot query field \"goodbye\" on type \"Query\". (line 1, column 9)"
+	if !tb.Failed() || !strings.Contains(tb.String(), want) {
+		t.Errorf("got %q, want a failure containing %q", tb.String(), want)
+	}
+	if n := atomic.LoadInt32(&r.calls); n != 0 {
+		t.Errorf("resolver called %d times, want the query rejected before execution", n)
+	}
+}
+
+type failingMutationResolver struct{}
+
+func (r *failingMutationResolver) Hello() string {
+	return "Hello!"
+}
+
+func (r *failingMutationResolver) Items() []*failingItemResolver {
+	return []*failingItemResolver{{}, {err: fmt.Errorf("second")}}
+}
+
+func (r *failingMutationResolver) Fail(args struct{ Msg string }) (*string, error) {
+	return nil, fmt.Errorf("%s", args.Msg)
+}
+
+// failingMutationSchema reports the errors of mutation fields in document order, since they are
+// executed serially, and fails the value of the second of its items.
+var failingMutationSchema = graphql.MustParseSchema(`
+	type Query {
+		hello: String!
+		items: [Item!]!
+	}
+
+	type Mutation {
+		fail(msg: String!): String
+	}
+
+	type Item {
+		value: String
+	}
+`, &failingMutationResolver{})
+
+type failingItemResolver struct {
+	err error
+}
+
+func (r *failingItemResolver) Value() (*string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	v := "first"
+	return &v, nil
+}
+
+func TestRunTest_orderedErrors(t *testing.T) {
+	b := &errors.QueryError{Message: "b", Path: []interface{}{"b"}, ResolverError: fmt.Errorf("b")}
+	a := &errors.QueryError{Message: "a", Path: []interface{}{"a"}, ResolverError: fmt.Errorf("a")}
+	tests := []struct {
+		name     string
+		expected []*errors.QueryError
+		opts     []Option
+		fail     bool
+	}{
+		{name: "sorted by path", expected: []*errors.QueryError{a, b}},
+		{name: "document order", expected: []*errors.QueryError{b, a}, opts: []Option{WithOrderedErrors()}},
+		{name: "wrong order", expected: []*errors.QueryError{a, b}, opts: []Option{WithOrderedErrors()}, fail: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := runFake(func(tb testing.TB) {
+				runTest(tb, &Test{
+					Schema:         failingMutationSchema,
+					Query:          `mutation { b: fail(msg: "b") a: fail(msg: "a") }`,
+					ExpectedResult: `{"b": null, "a": null}`,
+					ExpectedErrors: tt.expected,
+				}, newRunConfig(tt.opts))
+			})
+
+			if tb.Failed() != tt.fail {
+				t.Errorf("got failed %v, want %v; output:\n%s", tb.Failed(), tt.fail, tb.String())
+			}
+		})
+	}
+}
+
+func TestRunTest_strictErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		index interface{}
+		opts  []Option
+		fail  bool
+	}{
+		{name: "numeric index", index: float64(1)},
+		{name: "strict numeric index", index: float64(1), opts: []Option{WithStrictErrors()}, fail: true},
+		{name: "strict int index", index: 1, opts: []Option{WithStrictErrors()}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := runFake(func(tb testing.TB) {
+				runTest(tb, &Test{
+					Schema:         failingMutationSchema,
+					Query:          `{ items { value } }`,
+					ExpectedResult: `{"items": [{"value": "first"}, {"value": null}]}`,
+					ExpectedErrors: []*errors.QueryError{{
+						Message:       "second",
+						Path:          []interface{}{"items", tt.index, "value"},
+						ResolverError: fmt.Errorf("second"),
+					}},
+				}, newRunConfig(tt.opts))
+			})
+
+			if tb.Failed() != tt.fail {
+				t.Errorf("got failed %v, want %v; output:\n%s", tb.Failed(), tt.fail, tb.String())
+			}
+		})
+	}
+}
+
+// bigInt is a scalar serialized as a JSON number of arbitrary precision.
+type bigInt string
+
+func (bigInt) ImplementsGraphQLType(name string) bool {
+	return name == "BigInt"
+}
+
+func (n *bigInt) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("BigInt must be a string")
+	}
+	*n = bigInt(s)
+	return nil
+}
+
+func (n bigInt) MarshalJSON() ([]byte, error) {
+	return []byte(n), nil
+}
+
+type valuesResolver struct{}
+
+func (r *valuesResolver) Big() bigInt {
+	return "9007199254740993"
+}
+
+func (r *valuesResolver) Token() string {
+	return "a1b2c3"
+}
+
+func (r *valuesResolver) Content() string {
+	return "AAEC"
+}
+
+func (r *valuesResolver) ID() graphql.ID {
+	return "123"
+}
+
+func (r *valuesResolver) Settings() string {
+	return `{"theme": "dark", "size": 12}`
+}
+
+var valuesSchema = graphql.MustParseSchema(`
+	scalar BigInt
+
+	type Query {
+		big: BigInt!
+		token: String!
+		content: String!
+		id: ID!
+		settings: String!
+	}
+`, &valuesResolver{})
+
+// valuesTest is a case of runValuesTests, whose output, if set, must be contained in the
+// failure message.
+type valuesTest struct {
+	name     string
+	expected string
+	opts     []Option
+	fail     bool
+	output   string
+}
+
+// runValuesTests runs query against valuesSchema with the expected result and options of each
+// case, and checks whether it fails.
+func runValuesTests(t *testing.T, query string, tests []valuesTest) {
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := runFake(func(tb testing.TB) {
+				runTest(tb, &Test{
+					Schema:         valuesSchema,
+					Query:          query,
+					ExpectedResult: tt.expected,
+				}, newRunConfig(tt.opts))
+			})
+
+			if tb.Failed() != tt.fail {
+				t.Errorf("got failed %v, want %v; output:\n%s", tb.Failed(), tt.fail, tb.String())
+			}
+			if tt.output != "" && !strings.Contains(tb.String(), tt.output) {
+				t.Errorf("output %q does not contain %q", tb.String(), tt.output)
+			}
+		})
+	}
+}
+
+func TestRunTest_jsonNumber(t *testing.T) {
+	runValuesTests(t, `{ big }`, []valuesTest{
+		{name: "rounded", expected: `{"big": 9007199254740992}`},
+		{name: "exact", expected: `{"big": 9007199254740993}`, opts: []Option{WithJSONNumber()}},
+		{name: "off by one", expected: `{"big": 9007199254740992}`, opts: []Option{WithJSONNumber()}, fail: true},
+	})
+}
+
+func TestRunTest_placeholder(t *testing.T) {
+	runValuesTests(t, `{ token }`, []valuesTest{
+		{name: "default", expected: `{"token": "<ANY>"}`},
+		{name: "custom", expected: `{"token": "<TOKEN>"}`, opts: []Option{WithPlaceholder("<TOKEN>")}},
+		{name: "default replaced", expected: `{"token": "<ANY>"}`, opts: []Option{WithPlaceholder("<TOKEN>")}, fail: true},
+		{name: "disabled", expected: `{"token": "<ANY>"}`, opts: []Option{WithPlaceholder("")}, fail: true},
+	})
+}
+
+func TestRunTest_base64Fields(t *testing.T) {
+	runValuesTests(t, `{ content }`, []valuesTest{
+		{name: "equal", expected: `{"content": "AAEC"}`, opts: []Option{WithBase64Fields("content")}},
+		{
+			name:     "different",
+			expected: `{"content": "AwQF"}`,
+			opts:     []Option{WithBase64Fields("content")},
+			fail:     true,
+			output:   "got (3 bytes):\n00000000  00 01 02",
+		},
+	})
+}
+
+func TestRunTest_numericIDs(t *testing.T) {
+	runValuesTests(t, `{ id }`, []valuesTest{
+		{name: "number", expected: `{"id": 123}`, fail: true},
+		{name: "number at path", expected: `{"id": 123}`, opts: []Option{WithNumericIDs("data.id")}},
+		{name: "number anywhere", expected: `{"id": 123}`, opts: []Option{WithNumericIDs()}},
+		{name: "other number", expected: `{"id": 124}`, opts: []Option{WithNumericIDs("data.id")}, fail: true},
+	})
+}
+
+func TestRunTest_jsonStringFields(t *testing.T) {
+	runValuesTests(t, `{ settings }`, []valuesTest{
+		{name: "text", expected: `{"settings": "{\"size\":12,\"theme\":\"dark\"}"}`, fail: true},
+		{
+			name:     "structure",
+			expected: `{"settings": "{\"size\":12,\"theme\":\"dark\"}"}`,
+			opts:     []Option{WithJSONStringFields("data.settings")},
+		},
+		{
+			name:     "other structure",
+			expected: `{"settings": "{\"size\":14,\"theme\":\"dark\"}"}`,
+			opts:     []Option{WithJSONStringFields("data.settings")},
+			fail:     true,
+		},
+	})
+}
+
+func TestRunTest_withoutColor(t *testing.T) {
+	defer func(enabled bool) { colorEnabled = enabled }(colorEnabled)
+	colorEnabled = true
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		colored bool
+	}{
+		{name: "terminal", colored: true},
+		{name: "without color", opts: []Option{WithoutColor()}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := runFake(func(tb testing.TB) {
+				runTest(tb, &Test{
+					Schema:         valuesSchema,
+					Query:          `{ token }`,
+					ExpectedResult: `{"token": "d4e5f6"}`,
+				}, newRunConfig(tt.opts))
+			})
+
+			if !tb.Failed() {
+				t.Fatal("test did not fail")
+			}
+			if colored := strings.Contains(tb.String(), colorRed); colored != tt.colored {
+				t.Errorf("got colored %v, want %v; output:\n%q", colored, tt.colored, tb.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,1820 @@
+package gqltesting_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+type contextKey string
+
+type helloResolver struct{}
+
+func (r *helloResolver) Hello(ctx context.Context) string {
+	if name, ok := ctx.Value(contextKey("name")).(string); ok {
+		return "Hello " + name + "!"
+	}
+	return "Hello world!"
+}
+
+func (r *helloResolver) Greet(args struct{ Name string }) string {
+	return "Hello " + args.Name + "!"
+}
+
+func (r *helloResolver) Average() float64 {
+	return 10.0 / 3
+}
+
+func (r *helloResolver) HasDeadline(ctx context.Context) bool {
+	_, ok := ctx.Deadline()
+	return ok
+}
+
+func (r *helloResolver) Fail() (*string, error) {
+	return nil, errors.New("user 42 not found")
+}
+
+type codedError struct {
+	code string
+}
+
+func (e codedError) Error() string {
+	return "error " + e.code
+}
+
+func (e codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func (r *helloResolver) Secret() (*string, error) {
+	return nil, codedError{code: "UNAUTHENTICATED"}
+}
+
+type user struct {
+	id   string
+	name string
+}
+
+func (u *user) ID() graphql.ID {
+	return graphql.ID(u.id)
+}
+
+func (u *user) Name() string {
+	return u.name
+}
+
+func (r *helloResolver) Users() []*user {
+	return []*user{{id: "1", name: "Alice"}, {id: "2", name: "Bob"}}
+}
+
+func (r *helloResolver) RequestID() graphql.ID {
+	return "5f0c8a4e-3b7d-4c1e-9a2f-6d8e1b0c7a93"
+}
+
+func (r *helloResolver) Now() string {
+	return time.Now().Format(time.RFC3339)
+}
+
+func (r *helloResolver) Epoch() string {
+	return "2020-01-01T01:00:00.000+01:00"
+}
+
+func (r *helloResolver) Required() (string, error) {
+	return "", errors.New("required field failed")
+}
+
+var helloSchema = graphql.MustParseSchema(`
+	type Query {
+		hello: String!
+		hasDeadline: Boolean!
+		average: Float!
+		greet(name: String!): String!
+		fail: String
+		secret: String
+		users: [User!]!
+		requestId: ID!
+		now: String!
+		epoch: String!
+		required: String!
+	}
+
+	type User {
+		id: ID!
+		name: String!
+	}
+`, &helloResolver{})
+
+func TestRunTest_options(t *testing.T) {
+	t.Run("WithContext overrides Test.Context", func(t *testing.T) {
+		gqltesting.RunTest(t, &gqltesting.Test{
+			Context:        context.WithValue(context.Background(), contextKey("name"), "ignored"),
+			Schema:         helloSchema,
+			Query:          `{ hello }`,
+			ExpectedResult: `{"hello": "Hello gopher!"}`,
+		}, gqltesting.WithContext(context.WithValue(context.Background(), contextKey("name"), "gopher")))
+	})
+
+	t.Run("context values", func(t *testing.T) {
+		test := &gqltesting.Test{
+			Context:       context.WithValue(context.Background(), contextKey("name"), "base"),
+			Schema:        helloSchema,
+			Query:         `{ hello }`,
+			ContextValues: map[interface{}]interface{}{contextKey("name"): "field"},
+		}
+		test.ExpectedResult = `{"hello": "Hello field!"}`
+		gqltesting.RunTest(t, test)
+		test.ExpectedResult = `{"hello": "Hello option!"}`
+		gqltesting.RunTest(t, test, gqltesting.WithContextValue(contextKey("name"), "option"))
+	})
+
+	t.Run("WithTimeout sets a deadline", func(t *testing.T) {
+		gqltesting.RunTest(t, &gqltesting.Test{
+			Schema:         helloSchema,
+			Query:          `{ hasDeadline }`,
+			ExpectedResult: `{"hasDeadline": true}`,
+		}, gqltesting.WithTimeout(time.Minute))
+	})
+
+	t.Run("WithParallel", func(t *testing.T) {
+		gqltesting.RunTests(t, []*gqltesting.Test{
+			{
+				Name:           "hello",
+				Schema:         helloSchema,
+				Query:          `{ hello }`,
+				ExpectedResult: `{"hello": "Hello world!"}`,
+			},
+			{
+				Name:           "default deadline",
+				Schema:         helloSchema,
+				Query:          `{ hasDeadline }`,
+				ExpectedResult: `{"hasDeadline": true}`,
+			},
+		}, gqltesting.WithParallel())
+	})
+}
+
+func TestRunTest_errorMessageMatchers(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:                helloSchema,
+			Query:                 `{ fail }`,
+			ExpectedResult:        `{"fail": null}`,
+			ExpectedErrorsContain: []string{"not found"},
+			ExpectedErrors:        []*gqlerrors.QueryError{{Path: []interface{}{"fail"}}},
+		},
+		{
+			Schema:               helloSchema,
+			Query:                `{ fail }`,
+			ExpectedResult:       `{"fail": null}`,
+			ExpectedErrorRegexps: []*regexp.Regexp{regexp.MustCompile(`^user \d+ not found$`)},
+		},
+	})
+}
+
+func TestRunTest_errorExtensions(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         helloSchema,
+			Query:          `{ secret }`,
+			ExpectedResult: `{"secret": null}`,
+			ExpectedErrorExtensions: []map[string]interface{}{
+				{"code": "UNAUTHENTICATED"},
+			},
+		},
+		{
+			Schema:                  helloSchema,
+			Query:                   `{ fail }`,
+			ExpectedResult:          `{"fail": null}`,
+			ExpectedErrorExtensions: []map[string]interface{}{{}},
+		},
+	})
+}
+
+func TestRunTest_goldenFile(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:     helloSchema,
+		Query:      `{ hello }`,
+		GoldenFile: "testdata/hello.json",
+	})
+}
+
+func TestRunTest_unorderedLists(t *testing.T) {
+	test := &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ users { name } }`,
+		ExpectedResult: `{"users": [{"name": "Bob"}, {"name": "Alice"}]}`,
+	}
+	t.Run("all lists", func(t *testing.T) {
+		gqltesting.RunTest(t, test, gqltesting.WithUnorderedLists())
+	})
+	t.Run("by path", func(t *testing.T) {
+		gqltesting.RunTest(t, test, gqltesting.WithUnorderedLists("data.users"))
+	})
+}
+
+func TestRunTest_ignoreFields(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ users { id name } }`,
+		ExpectedResult: `{"users": [{"name": "Alice"}, {"id": "ignored", "name": "Bob"}]}`,
+		IgnoreFields:   []string{"data.users[].id"},
+	})
+}
+
+func TestRunTest_fieldMatchers(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ hello requestId now }`,
+		ExpectedResult: `{"hello": "Hello world!"}`,
+		FieldMatchers: map[string]gqltesting.FieldMatcher{
+			"data.requestId": gqltesting.IsUUID,
+			"data.now":       gqltesting.IsRFC3339Time,
+		},
+	})
+}
+
+func TestRunTest_expectNoErrors(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ hello }`,
+		ExpectedResult: `{"hello": "Hello world!"}`,
+		ExpectNoErrors: true,
+	})
+}
+
+func TestRunTest_strictValidation(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ hello }`,
+		ExpectedResult: `{"hello": "Hello world!"}`,
+	}, gqltesting.WithStrictValidation())
+}
+
+func TestRunTest_variablesJSON(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         helloSchema,
+			Query:          `query($name: String!) { greet(name: $name) }`,
+			VariablesJSON:  `{"name": "gopher"}`,
+			ExpectedResult: `{"greet": "Hello gopher!"}`,
+		},
+		{
+			Schema:         helloSchema,
+			Query:          `query($name: String!) { greet(name: $name) }`,
+			VariablesFile:  "testdata/variables.json",
+			ExpectedResult: `{"greet": "Hello file!"}`,
+		},
+	})
+}
+
+func TestRunTest_floatTolerance(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ average }`,
+		ExpectedResult: `{"average": 3.3333334}`,
+	}, gqltesting.WithFloatTolerance(1e-6))
+}
+
+func TestRunTest_maxResolverCalls(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			users: [User!]!
+		}
+
+		type User {
+			id: ID!
+			name: String!
+		}
+	`, &helloResolver{}, graphql.Tracer(gqltesting.CountResolverCalls(nil)))
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         schema,
+		Query:          `{ users { name } }`,
+		ExpectedResult: `{"users": [{"name": "Alice"}, {"name": "Bob"}]}`,
+		MaxResolverCalls: map[string]int{
+			"Query.users": 1,
+			"User.name":   2,
+		},
+	})
+}
+
+func TestRunTestWithTrace(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+	`, &helloResolver{}, graphql.Tracer(gqltesting.Instrument(nil)))
+
+	clock := time.Unix(0, 0)
+	tick := func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+
+	tr, ok := gqltesting.RunTestWithTrace(t, &gqltesting.Test{
+		Schema:           schema,
+		Query:            `{ hello }`,
+		ExpectedResult:   `{"hello": "Hello world!"}`,
+		MaxFieldDuration: map[string]time.Duration{"Query.hello": time.Millisecond},
+	}, gqltesting.WithClock(tick))
+	if !ok {
+		t.Fatal("test failed")
+	}
+	if len(tr.Resolvers) != 1 {
+		t.Fatalf("got %d resolver traces, want 1", len(tr.Resolvers))
+	}
+	if res := tr.Resolvers[0]; res.ParentType != "Query" || res.FieldName != "hello" || res.Duration != time.Millisecond {
+		t.Errorf("unexpected resolver trace: %+v", res)
+	}
+	if tr.Duration != 3*time.Millisecond {
+		t.Errorf("got duration %s, want %s", tr.Duration, 3*time.Millisecond)
+	}
+}
+
+func BenchmarkRunBenchmark(b *testing.B) {
+	test := &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ users { id name } }`,
+		ExpectedResult: `{"users": [{"id": "1", "name": "Alice"}, {"id": "2", "name": "Bob"}]}`,
+	}
+	b.Run("serial", func(b *testing.B) {
+		gqltesting.RunBenchmark(b, test)
+	})
+	b.Run("parallel", func(b *testing.B) {
+		gqltesting.RunBenchmark(b, test, gqltesting.WithParallel())
+	})
+	b.Run("prepared", func(b *testing.B) {
+		gqltesting.RunBenchmark(b, test, gqltesting.WithPreparedQuery())
+	})
+}
+
+func TestRunTest_preparedQuery(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:           "concurrent executions",
+			Schema:         helloSchema,
+			Query:          `query Greet($name: String!) { greet(name: $name) }`,
+			Variables:      map[string]interface{}{"name": "you"},
+			ExpectedResult: `{"greet": "Hello you!"}`,
+		},
+		{
+			Name:   "syntax error",
+			Schema: helloSchema,
+			Query:  `{ hello`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   `syntax error: unexpected "", expecting Ident`,
+				Locations: []gqlerrors.Location{{Line: 1, Column: 8}},
+			}},
+		},
+	}, gqltesting.WithPreparedQuery(), gqltesting.WithConcurrency(4))
+}
+
+func TestRunTest_expectedExtensions(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:             helloSchema,
+		Query:              `{ hello }`,
+		ExpectedResult:     `{"hello": "Hello world!"}`,
+		ExpectedExtensions: `null`,
+	})
+}
+
+func TestRunOperationNameTests(t *testing.T) {
+	gqltesting.RunOperationNameTests(t, helloSchema, `
+		query GetHello {
+			hello
+		}
+
+		query GetAverage {
+			average
+		}
+	`, map[string]string{
+		"GetHello":   `{"hello": "Hello world!"}`,
+		"GetAverage": `{"average": 3.3333333333333335}`,
+	})
+}
+
+func TestRunTest_expectNullData(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:                helloSchema,
+		Query:                 `{ required }`,
+		ExpectNullData:        true,
+		ExpectedErrorsContain: []string{"required field failed"},
+	})
+}
+
+func TestRunTest_errorMatcher(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:           "ErrorCount",
+			Schema:         helloSchema,
+			Query:          `{ fail secret }`,
+			ExpectedResult: `{"fail": null, "secret": null}`,
+			ErrorMatcher:   gqltesting.ErrorCount(2),
+		},
+		{
+			Name:           "AnyError",
+			Schema:         helloSchema,
+			Query:          `{ fail }`,
+			ExpectedResult: `{"fail": null}`,
+			ErrorMatcher:   gqltesting.AnyError(),
+		},
+		{
+			Name:           "ExactErrors",
+			Schema:         helloSchema,
+			Query:          `{ hello }`,
+			ExpectedResult: `{"hello": "Hello world!"}`,
+			ErrorMatcher:   gqltesting.ExactErrors(),
+		},
+		{
+			Name:           "custom",
+			Schema:         helloSchema,
+			Query:          `{ secret }`,
+			ExpectedResult: `{"secret": null}`,
+			ErrorMatcher: gqltesting.ErrorMatcherFunc(func(got []*gqlerrors.QueryError) error {
+				if len(got) != 1 || got[0].Path[0] != "secret" {
+					return errors.New("want exactly one error at secret")
+				}
+				return nil
+			}),
+		},
+	})
+}
+
+func TestRunTest_expectPartial(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:                helloSchema,
+		Query:                 `{ hello fail }`,
+		ExpectedResult:        `{"hello": "Hello world!", "fail": null}`,
+		ExpectedErrorsContain: []string{"not found"},
+		ExpectPartial:         true,
+	})
+}
+
+type legacyHelloResolver struct{}
+
+func (r *legacyHelloResolver) Hello() string {
+	return "Hi!"
+}
+
+func TestRunTestMatrix(t *testing.T) {
+	legacySchema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+	`, &legacyHelloResolver{})
+
+	gqltesting.RunTestMatrix(t, map[string]*graphql.Schema{
+		"current": helloSchema,
+		"legacy":  legacySchema,
+	}, &gqltesting.Test{
+		Query:          `{ hello }`,
+		ExpectedResult: `{"hello": "Hello world!"}`,
+		ExpectedResultBySchema: map[string]string{
+			"legacy": `{"hello": "Hi!"}`,
+		},
+	})
+}
+
+func TestRunTestHTTP(t *testing.T) {
+	gqltesting.RunTestHTTP(t, &gqltesting.Test{
+		Schema:           helloSchema,
+		Query:            `{ hello }`,
+		ExpectedResponse: `{"data": {"hello": "Hello world!"}}`,
+	})
+
+	gqltesting.RunTestHTTP(t, &gqltesting.Test{
+		Schema: helloSchema,
+		Query:  `{ fail }`,
+		ExpectedResponse: `{
+			"errors": [{"message": "user 42 not found", "path": ["fail"]}],
+			"data": {"fail": null}
+		}`,
+	})
+}
+
+func TestRunTest_concurrency(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ hello users { id name } }`,
+		ExpectedResult: `{"hello": "Hello world!", "users": [{"id": "1", "name": "Alice"}, {"id": "2", "name": "Bob"}]}`,
+	}, gqltesting.WithConcurrency(8))
+}
+
+func TestAssertSchemaError(t *testing.T) {
+	gqltesting.AssertSchemaError(t, `
+type Query {
+	hello: Greeting
+}`, nil, `Unknown type "Greeting"`, gqlerrors.Location{Line: 3, Column: 9})
+
+	gqltesting.AssertSchemaError(t, `
+		type Query {
+			goodbye: String!
+		}
+	`, &helloResolver{}, `missing method for field "goodbye"`)
+}
+
+func TestRunVariableCoercionTest(t *testing.T) {
+	const query = `query Greet($name: String!) { greet(name: $name) }`
+	loc := []gqlerrors.Location{{Line: 1, Column: 13}}
+
+	gqltesting.RunVariableCoercionTest(t, helloSchema, query, `{}`, &gqlerrors.QueryError{
+		Message:   `Variable "name" of required type "String!" was not provided.`,
+		Locations: loc,
+		Rule:      "VariablesOfCorrectType",
+	})
+	gqltesting.RunVariableCoercionTest(t, helloSchema, query, `{"name": null}`, &gqlerrors.QueryError{
+		Message:   "Variable \"name\" has invalid value null.\nExpected type \"String!\", found null.",
+		Locations: loc,
+		Rule:      "VariablesOfCorrectType",
+	})
+	gqltesting.RunVariableCoercionTest(t, helloSchema, query, `{"name": 42}`, &gqlerrors.QueryError{
+		Message:   "Variable \"name\" has invalid value 42.\nExpected type \"String!\", found 42.",
+		Locations: loc,
+		Rule:      "VariablesOfCorrectType",
+	})
+	gqltesting.RunVariableCoercionTest(t, helloSchema, query, `{"name": "you", "unknown": 1}`, &gqlerrors.QueryError{
+		Message: `Variable "unknown" is not defined by any operation.`,
+		Rule:    "NoUnknownVariables",
+	})
+	gqltesting.RunVariableCoercionTest(t, helloSchema, query, `{"name": "you"}`, nil)
+}
+
+func TestTimeEqual(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ epoch }`,
+		ExpectedResult: `{}`,
+		FieldMatchers: map[string]gqltesting.FieldMatcher{
+			"data.epoch": gqltesting.TimeEqual("2020-01-01T00:00:00Z"),
+		},
+	})
+
+	if err := gqltesting.TimeEqual("2020-01-01T00:00:00Z")("2020-01-01T00:00:01Z"); err == nil {
+		t.Error("TimeEqual matched a different instant")
+	}
+}
+
+func TestRunIntrospectionSnapshot(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+	`, nil)
+	gqltesting.RunIntrospectionSnapshot(t, schema, "testdata/introspection.json")
+}
+
+// sdlSchema exercises every kind of definition AssertSDL prints, declared out of order.
+const sdlSchema = `
+	schema {
+		query: Root
+		mutation: Mutation
+	}
+
+	"An instant, in RFC 3339 format."
+	scalar Time
+
+	directive @cached(ttl: Int = 60) on FIELD | QUERY
+
+	type Root {
+		users(order: Order = ASC, first: Int!, after: ID): [User!]!
+		search(text: String!): [Result!]
+		node(id: ID!): Node
+	}
+
+	type Mutation {
+		createUser(input: UserInput!): User
+	}
+
+	"""
+	An account.
+
+	Every user has a unique name.
+	"""
+	type User implements Node {
+		name: String!
+		id: ID!
+		login: String @deprecated(reason: "Use name instead.")
+		nick: String @deprecated
+		createdAt: Time!
+	}
+
+	interface Node {
+		id: ID!
+	}
+
+	type Group implements Node {
+		id: ID!
+		members: [User]
+	}
+
+	union Result = User | Group
+
+	enum Order {
+		DESC
+		"Oldest first."
+		ASC
+	}
+
+	input UserInput {
+		tags: [String!] = []
+		name: String!
+	}
+`
+
+func TestAssertSDL(t *testing.T) {
+	schema := graphql.MustParseSchema(sdlSchema, nil, graphql.UseStringDescriptions())
+	gqltesting.AssertSDL(t, schema, "testdata/schema.graphql")
+
+	// The printed SDL describes the same schema.
+	sdl, err := ioutil.ReadFile("testdata/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gqltesting.AssertSchemaEqual(t, schema, graphql.MustParseSchema(string(sdl), nil, graphql.UseStringDescriptions()))
+}
+
+func TestAssertFieldDeprecated(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String! @deprecated(reason: "Use greet instead.")
+			greet: String!
+		}
+	`, nil)
+	gqltesting.AssertFieldDeprecated(t, schema, "Query", "hello", "Use greet instead.")
+}
+
+func TestRunTest_httpRoundTrip(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:           "context values",
+			Schema:         helloSchema,
+			Query:          `{ hello }`,
+			ContextValues:  map[interface{}]interface{}{contextKey("name"): "HTTP"},
+			ExpectedResult: `{"hello": "Hello HTTP!"}`,
+		},
+		{
+			Name:                  "errors",
+			Schema:                helloSchema,
+			Query:                 `{ fail }`,
+			ExpectedResult:        `{"fail": null}`,
+			ExpectedErrors:        []*gqlerrors.QueryError{{Path: []interface{}{"fail"}}},
+			ExpectedErrorsContain: []string{"user 42 not found"},
+		},
+		{
+			Name:   "validation errors",
+			Schema: helloSchema,
+			Query:  `{ goodbye }`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   `Cannot query field "goodbye" on type "Query".`,
+				Locations: []gqlerrors.Location{{Line: 1, Column: 3}},
+				Rule:      "FieldsOnCorrectType",
+			}},
+		},
+	}, gqltesting.WithHTTPRoundTrip())
+}
+
+func TestRunTest_caseInsensitiveKeys(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ request_id: requestId, Users: users { ID: id } }`,
+		ExpectedResult: `{"requestId": "5f0c8a4e-3b7d-4c1e-9a2f-6d8e1b0c7a93", "users": [{"id": "1"}, {"id": "2"}]}`,
+	}, gqltesting.WithCaseInsensitiveKeys())
+}
+
+func TestParseAndValidate(t *testing.T) {
+	doc, errs := gqltesting.ParseAndValidate(helloSchema, `
+		query Users {
+			people: users @include(if: true) { ...userFields }
+		}
+		fragment userFields on User {
+			id
+		}
+	`)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(doc.Operations) != 1 || doc.Operations[0].Type != "query" || doc.Operations[0].Name != "Users" {
+		t.Fatalf("unexpected operations: %+v", doc.Operations)
+	}
+	people := doc.Operations[0].Selections[0]
+	if people.Alias != "people" || people.Name != "users" || people.Directives[0] != "include" || people.Selections[0].Kind != gqltesting.FragmentSpreadSelection {
+		t.Errorf("unexpected selection: %+v", people)
+	}
+	if doc.HasField("__typename") || !doc.HasField("id") {
+		t.Error("HasField reported the wrong fields")
+	}
+
+	if _, errs := gqltesting.ParseAndValidate(helloSchema, `{ goodbye }`); len(errs) != 1 {
+		t.Errorf("got %d errors, want 1", len(errs))
+	}
+	if doc, errs := gqltesting.ParseAndValidate(helloSchema, `{`); doc != nil || len(errs) != 1 {
+		t.Errorf("got %v, %v for a syntax error", doc, errs)
+	}
+
+	if _, errs := gqltesting.ParseAndValidate(helloSchema, `query Greet($name: String!) { greet(name: $name) }`); len(errs) > 0 {
+		t.Errorf("got errors %v for a required variable, want the document to be valid", errs)
+	}
+	if _, errs := gqltesting.ParseAndValidate(helloSchema, `query Greet($name: String! = "you") { greet(name: $name) }`); len(errs) != 1 || errs[0].Rule != "DefaultValuesOfCorrectType" {
+		t.Errorf("got errors %v, want the default value of the required variable to be reported", errs)
+	}
+}
+
+type filterInput struct {
+	Role   string
+	Active bool
+}
+
+type searchArgs struct {
+	Filter filterInput
+	Limit  int32
+}
+
+type spyingSearchResolver struct {
+	spy *gqltesting.SpyResolver
+}
+
+func (r *spyingSearchResolver) Search(args searchArgs) []string {
+	r.spy.Record("Query.search", args)
+	return nil
+}
+
+func TestSpyResolver(t *testing.T) {
+	spy := &gqltesting.SpyResolver{}
+	schema := graphql.MustParseSchema(`
+		enum Role {
+			ADMIN
+			MEMBER
+		}
+
+		input Filter {
+			role: Role!
+			active: Boolean = true
+		}
+
+		type Query {
+			search(filter: Filter!, limit: Int = 10): [String!]!
+		}
+	`, &spyingSearchResolver{spy: spy})
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         schema,
+		Query:          `{ search(filter: {role: ADMIN}) }`,
+		ExpectedResult: `{"search": []}`,
+	})
+	spy.AssertCalls(t, "Query.search", searchArgs{Filter: filterInput{Role: "ADMIN", Active: true}, Limit: 10})
+}
+
+func TestRunTest_expectedLimitError(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			users: [User!]!
+		}
+
+		type User {
+			id: ID!
+			name: String!
+		}
+	`, &helloResolver{}, graphql.MaxDepth(1))
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:             schema,
+		Query:              `{ users { name } }`,
+		ExpectedLimitError: gqltesting.MaxDepthExceeded,
+	})
+}
+
+func TestRunTest_schemaValidationRules(t *testing.T) {
+	const sdl = `
+		type Query {
+			users: [User!]!
+		}
+
+		type User {
+			id: ID!
+			name: String!
+		}
+	`
+	limited := graphql.MustParseSchema(sdl, &helloResolver{}, graphql.MaxDepth(1))
+	unlimited := graphql.MustParseSchema(sdl, &helloResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:   "rule configured on the schema",
+			Schema: limited,
+			Query:  `{ users { name } }`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   `Field "name" has depth 2 that exceeds max depth 1`,
+				Locations: []gqlerrors.Location{{Line: 1, Column: 11}},
+				Rule:      gqltesting.MaxDepthExceeded,
+			}},
+		},
+		{
+			Name:           "same query without the rule",
+			Schema:         unlimited,
+			Query:          `{ users { name } }`,
+			ExpectedResult: `{"users": [{"name": "Alice"}, {"name": "Bob"}]}`,
+		},
+	})
+}
+
+func TestAssertSchemaEqual(t *testing.T) {
+	a := graphql.MustParseSchema(`
+		type Query {
+			user(id: ID!, name: String): User
+			role: Role!
+		}
+
+		type User {
+			id: ID!
+			name: String!
+		}
+
+		enum Role {
+			ADMIN
+			MEMBER
+		}
+	`, nil)
+	b := graphql.MustParseSchema(`
+		enum Role { MEMBER ADMIN }
+		type User { name: String! id: ID! }
+		type Query { role: Role! user(name: String, id: ID!): User }
+	`, nil)
+	gqltesting.AssertSchemaEqual(t, a, b)
+}
+
+func TestParseStubSchema(t *testing.T) {
+	schema := gqltesting.MustParseStubSchema(`
+		scalar Time
+
+		enum Role {
+			ADMIN
+			MEMBER
+		}
+
+		type Query {
+			viewer: User!
+			users(first: Int = 10): [User!]
+		}
+
+		type Mutation {
+			rename(name: String!): User
+		}
+
+		type User {
+			id: ID!
+			name: String
+			role: Role!
+			age: Int!
+			createdAt: Time!
+			is_admin: Boolean!
+		}
+	`)
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query:  `{ viewer { id name role age createdAt is_admin } users { id } }`,
+			ExpectedResult: `{
+				"viewer": {"id": "", "name": "", "role": "ADMIN", "age": 0, "createdAt": "", "is_admin": false},
+				"users": [{"id": ""}]
+			}`,
+		},
+		{
+			Schema:         schema,
+			Query:          `mutation { rename(name: "x") { id } }`,
+			ExpectedResult: `{"rename": {"id": ""}}`,
+		},
+	})
+
+	if _, err := gqltesting.ParseStubSchema(`
+		type Query {
+			node: Node
+		}
+
+		type Node {
+			parent: Node
+		}
+	`); err == nil || !strings.Contains(err.Error(), `recursive type "Node"`) {
+		t.Errorf("got error %v for a recursive type", err)
+	}
+}
+
+func TestAssertFieldPresent(t *testing.T) {
+	schema := gqltesting.MustParseStubSchema(`
+		type Query {
+			users: [User!]!
+		}
+
+		type User {
+			name: String!
+			email: String
+		}
+	`)
+	query := `query($private: Boolean!) { users { name email @skip(if: $private) } }`
+
+	result := schema.Exec(context.Background(), query, "", map[string]interface{}{"private": false})
+	gqltesting.AssertFieldPresent(t, result, "users[].email")
+	gqltesting.AssertFieldPresent(t, result, "data.users[0].name")
+
+	result = schema.Exec(context.Background(), query, "", map[string]interface{}{"private": true})
+	gqltesting.AssertFieldAbsent(t, result, "users[].email")
+	gqltesting.AssertFieldAbsent(t, result, "viewer.email")
+}
+
+type newUser struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+type createUserResolver struct{}
+
+func (r *createUserResolver) CreateUser(args struct {
+	Input struct {
+		Name  string
+		Roles *[]string
+	}
+}) string {
+	if args.Input.Roles == nil {
+		return args.Input.Name
+	}
+	return args.Input.Name + " " + strings.Join(*args.Input.Roles, ",")
+}
+
+func TestRunTest_structVariables(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		input NewUser {
+			name: String!
+			roles: [String!]
+		}
+
+		type Query {
+			createUser(input: NewUser!): String!
+		}
+	`, &createUserResolver{})
+	query := `query($input: NewUser!) { createUser(input: $input) }`
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:           "struct",
+			Schema:         schema,
+			Query:          query,
+			Variables:      map[string]interface{}{"input": newUser{Name: "alice", Roles: []string{"admin"}}},
+			ExpectedResult: `{"createUser": "alice admin"}`,
+		},
+		{
+			Name:           "pointer with omitted field",
+			Schema:         schema,
+			Query:          query,
+			Variables:      map[string]interface{}{"input": &newUser{Name: "bob"}},
+			ExpectedResult: `{"createUser": "bob"}`,
+		},
+	})
+}
+
+type callLogResolver struct{}
+
+func (r *callLogResolver) Names(ctx context.Context, args struct{ IDs []string }) []string {
+	gqltesting.CallLogFromContext(ctx).Add("users " + strings.Join(args.IDs, ","))
+	return args.IDs
+}
+
+func (r *callLogResolver) Version() string {
+	return "1"
+}
+
+func TestRunTest_expectedCallLog(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+			mutation: Mutation
+		}
+
+		type Query {
+			version: String!
+		}
+
+		type Mutation {
+			names(ids: [ID!]!): [String!]!
+		}
+	`, &callLogResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:            "one call per batch",
+			Schema:          schema,
+			Query:           `mutation { a: names(ids: ["1", "2"]) b: names(ids: ["3"]) }`,
+			ExpectedResult:  `{"a": ["1", "2"], "b": ["3"]}`,
+			ExpectedCallLog: []string{"users 1,2", "users 3"},
+		},
+		{
+			Name:            "no calls",
+			Schema:          schema,
+			Query:           `{ version }`,
+			ExpectedResult:  `{"version": "1"}`,
+			ExpectedCallLog: []string{},
+		},
+	})
+
+	gqltesting.CallLogFromContext(context.Background()).Add("outside of tests")
+}
+
+func (r *callLogResolver) Reset() bool {
+	return true
+}
+
+func TestRunTest_expectedResolutionOrder(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+			mutation: Mutation
+		}
+
+		type Query {
+			version: String!
+		}
+
+		type Mutation {
+			names(ids: [ID!]!): [String!]!
+			reset: Boolean!
+		}
+	`, &callLogResolver{}, graphql.Tracer(gqltesting.Instrument(nil)))
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:                  schema,
+		Query:                   `mutation { a: names(ids: ["1"]) reset b: names(ids: ["2"]) }`,
+		ExpectedResult:          `{"a": ["1"], "reset": true, "b": ["2"]}`,
+		ExpectedResolutionOrder: []string{"Mutation.names", "Mutation.reset", "Mutation.names"},
+	})
+}
+
+// apqHandler implements automatic persisted queries in front of relay.Handler.
+type apqHandler struct {
+	next    http.Handler
+	mu      sync.Mutex
+	queries map[string]string
+}
+
+func (h *apqHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+		Extensions    struct {
+			PersistedQuery struct {
+				SHA256Hash string `json:"sha256Hash"`
+			} `json:"persistedQuery"`
+		} `json:"extensions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	hash := params.Extensions.PersistedQuery.SHA256Hash
+	if params.Query != "" {
+		h.queries[hash] = params.Query
+	}
+	query, ok := h.queries[hash]
+	h.mu.Unlock()
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors": [{"message": "PersistedQueryNotFound"}]}`))
+		return
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"query":         query,
+		"operationName": params.OperationName,
+		"variables":     params.Variables,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	h.next.ServeHTTP(w, r)
+}
+
+func TestRunPersistedQueryTest(t *testing.T) {
+	h := &apqHandler{next: &relay.Handler{Schema: helloSchema}, queries: make(map[string]string)}
+
+	gqltesting.RunPersistedQueryTest(t, h, &gqltesting.PersistedQueryTest{
+		Query:          `{ hello }`,
+		ExpectedResult: `{"hello": "Hello world!"}`,
+	})
+}
+
+// Episode is an enum backed by a Go integer, serialized by its String method.
+type Episode int
+
+const (
+	NewHope Episode = iota
+	Empire
+	Jedi
+	Unknown
+)
+
+func (e Episode) String() string {
+	switch e {
+	case NewHope:
+		return "NEWHOPE"
+	case Empire:
+		return "EMPIRE"
+	case Jedi:
+		return "JEDI"
+	default:
+		return "unknown"
+	}
+}
+
+type episodeResolver struct{}
+
+func (r *episodeResolver) Episode(args struct{ Episode string }) Episode {
+	switch args.Episode {
+	case "EMPIRE":
+		return Empire
+	case "JEDI":
+		return Jedi
+	default:
+		return NewHope
+	}
+}
+
+func (r *episodeResolver) Unknown() *Episode {
+	e := Unknown
+	return &e
+}
+
+func TestRunEnumCoercionTest(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		enum Episode {
+			NEWHOPE
+			EMPIRE
+			JEDI
+		}
+
+		type Query {
+			episode(episode: Episode!): Episode!
+			unknown: Episode
+		}
+	`, &episodeResolver{})
+
+	t.Run("literal", func(t *testing.T) {
+		gqltesting.RunEnumCoercionTest(t, schema, `{ episode(episode: WRATH_OF_KHAN) }`, "", "Episode", "WRATH_OF_KHAN")
+	})
+	t.Run("literal differing in case", func(t *testing.T) {
+		gqltesting.RunEnumCoercionTest(t, schema, `{ episode(episode: empire) }`, "", "Episode", "empire")
+	})
+	t.Run("variable", func(t *testing.T) {
+		gqltesting.RunEnumCoercionTest(t, schema, `query($e: Episode!) { episode(episode: $e) }`, `{"e": "Jedi"}`, "Episode", "Jedi")
+	})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:           "custom enum serializes to its SDL value",
+			Schema:         schema,
+			Query:          `{ a: episode(episode: EMPIRE) b: episode(episode: JEDI) }`,
+			ExpectedResult: `{"a": "EMPIRE", "b": "JEDI"}`,
+		},
+		{
+			Name:           "custom enum outside of the SDL values",
+			Schema:         schema,
+			Query:          `{ unknown }`,
+			ExpectedResult: `{"unknown": null}`,
+			ExpectedErrorsContain: []string{
+				"Invalid value unknown.\nExpected type Episode, found unknown.",
+			},
+		},
+	})
+}
+
+type dateTime struct {
+	time.Time
+}
+
+func (dateTime) ImplementsGraphQLType(name string) bool {
+	return name == "DateTime"
+}
+
+func (d *dateTime) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return errors.New("DateTime must be a string")
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return errors.New("invalid DateTime " + strconv.Quote(s))
+	}
+	d.Time = t
+	return nil
+}
+
+type calendarResolver struct{}
+
+func (*calendarResolver) Weekday(args struct{ At dateTime }) string {
+	return args.At.Weekday().String()
+}
+
+func TestRunScalarParseErrorTest(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		scalar DateTime
+
+		type Query {
+			weekday(at: DateTime!): String!
+		}
+	`, &calendarResolver{})
+
+	t.Run("literal", func(t *testing.T) {
+		gqltesting.RunScalarParseErrorTest(t, schema, `{ weekday(at: "yesterday") }`, "", `invalid DateTime "yesterday"`, nil)
+	})
+	t.Run("literal of the wrong type", func(t *testing.T) {
+		gqltesting.RunScalarParseErrorTest(t, schema, `{ weekday(at: 3) }`, "", "DateTime must be a string", nil)
+	})
+	t.Run("variable", func(t *testing.T) {
+		gqltesting.RunScalarParseErrorTest(t, schema, `query($at: DateTime!) { weekday(at: $at) }`, `{"at": "yesterday"}`, `invalid DateTime "yesterday"`, nil)
+	})
+	t.Run("options", func(t *testing.T) {
+		gqltesting.RunScalarParseErrorTest(t, schema, `query($at: DateTime!) { weekday(at: $at) }`, `{"at": "yesterday"}`, `invalid DateTime "yesterday"`, nil,
+			gqltesting.WithStrictValidation(), gqltesting.WithHTTPRoundTrip())
+	})
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         schema,
+		Query:          `{ weekday(at: "2020-01-01T00:00:00Z") }`,
+		ExpectedResult: `{"weekday": "Wednesday"}`,
+	})
+}
+
+func TestRunTest_ignoreErrors(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Name:           "errors allowed",
+			Schema:         helloSchema,
+			Query:          `{ hello fail }`,
+			ExpectedResult: `{"hello": "Hello world!", "fail": null}`,
+		},
+		{
+			Name:                  "expectations still apply",
+			Schema:                helloSchema,
+			Query:                 `{ hello fail }`,
+			ExpectedResult:        `{"hello": "Hello world!", "fail": null}`,
+			ExpectedErrorsContain: []string{"not found"},
+		},
+	}, gqltesting.WithIgnoreErrors())
+}
+
+func TestRunTest_responseJSONSchema(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema: helloSchema,
+		Query:  `{ hello users { id name } }`,
+		ResponseJSONSchema: `{
+			"type": "object",
+			"required": ["hello", "users"],
+			"properties": {
+				"hello": {"type": "string", "pattern": "^Hello"},
+				"users": {"type": "array", "items": {"required": ["id", "name"]}}
+			}
+		}`,
+	})
+}
+
+type slowResolver struct{}
+
+func (r *slowResolver) Fast() string {
+	return "fast"
+}
+
+func (r *slowResolver) Slow(ctx context.Context) (*string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Minute):
+		s := "slow"
+		return &s, nil
+	}
+}
+
+func TestRunFieldTimeoutTest(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			fast: String!
+			slow: String
+		}
+	`, &slowResolver{})
+
+	gqltesting.RunFieldTimeoutTest(t, &gqltesting.Test{
+		Schema:         schema,
+		Query:          `{ fast slow }`,
+		ExpectedResult: `{"fast": "fast", "slow": null}`,
+	}, 10*time.Second, []interface{}{"slow"})
+}
+
+func TestRunTest_expectedResultValue(t *testing.T) {
+	type expectedUser struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema: helloSchema,
+		Query:  `{ hello users { id name } }`,
+		ExpectedResultValue: map[string]interface{}{
+			"hello": "Hello world!",
+			"users": []expectedUser{{ID: "1", Name: "Alice"}, {ID: "2", Name: "Bob"}},
+		},
+	})
+}
+
+func TestAssertErrorAt(t *testing.T) {
+	query := "{\n\thello\n\tnmae\n\tfail\n}"
+	result := helloSchema.Exec(context.Background(), query, "", nil)
+	gqltesting.AssertErrorAt(t, query, result, 3, 2)
+}
+
+func TestRunTests_concurrentCallers(t *testing.T) {
+	tests := []*gqltesting.Test{
+		{
+			Schema:         helloSchema,
+			Query:          `{ hello }`,
+			ExpectedResult: `{"hello": "Hello world!"}`,
+		},
+		{
+			Schema:                helloSchema,
+			Query:                 `{ users { id } fail }`,
+			ExpectedResult:        `{"users": [{"id": "1"}, {"id": "2"}], "fail": null}`,
+			ExpectedErrors:        []*gqlerrors.QueryError{{Path: []interface{}{"fail"}}},
+			ExpectedErrorsContain: []string{"not found"},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gqltesting.RunTests(t, tests)
+		}()
+	}
+	wg.Wait()
+
+	if tests[0].Context != nil {
+		t.Error("RunTests modified the test table")
+	}
+}
+
+func TestAssertIntrospectionDisabled(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+	`, &helloResolver{}, graphql.DisableIntrospection())
+
+	gqltesting.AssertIntrospectionDisabled(t, schema)
+}
+
+func TestRunTest_expectedErrorsSubset(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ hello fail secret }`,
+		ExpectedResult: `{"hello": "Hello world!", "fail": null, "secret": null}`,
+		ExpectedErrorsSubset: []*gqlerrors.QueryError{
+			{
+				Message:    "error UNAUTHENTICATED",
+				Path:       []interface{}{"secret"},
+				Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"},
+			},
+		},
+	})
+}
+
+type flagKey string
+
+type featureResolver struct{}
+
+func (r *featureResolver) Greeting(ctx context.Context) string {
+	if on, _ := ctx.Value(flagKey("emoji")).(bool); on {
+		return "hi 👋"
+	}
+	return "hi"
+}
+
+func TestRunFeatureFlagTest(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			greeting: String!
+		}
+	`, &featureResolver{})
+
+	gqltesting.RunFeatureFlagTest(t, &gqltesting.Test{
+		Schema: schema,
+		Query:  `{ greeting }`,
+	}, flagKey("emoji"), `{"greeting": "hi 👋"}`, `{"greeting": "hi"}`)
+}
+
+func TestRunIncrementalTest(t *testing.T) {
+	payloads := make(chan *gqltesting.IncrementalPayload, 2)
+	payloads <- &gqltesting.IncrementalPayload{Data: json.RawMessage(`{"user": {"id": "1"}}`), HasNext: true}
+	payloads <- &gqltesting.IncrementalPayload{Data: json.RawMessage(`{"name": "Alice"}`), Path: []interface{}{"user"}, Label: "details"}
+	close(payloads)
+
+	gqltesting.RunIncrementalTest(t, payloads, []gqltesting.ExpectedPayload{
+		{Data: `{"user": {"id": "1"}}`, HasNext: true},
+		{Data: `{"name": "Alice"}`, Path: []interface{}{"user"}, Label: "details"},
+	})
+}
+
+type suiteResolver struct {
+	record func(event string)
+}
+
+func (r *suiteResolver) Hello() string {
+	r.record("hello")
+	return "Hello world!"
+}
+
+func TestSuite(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	tests := []*gqltesting.Test{
+		{Query: `{ hello }`, ExpectedResult: `{"hello": "Hello world!"}`},
+		{Query: `{ greeting: hello }`, ExpectedResult: `{"greeting": "Hello world!"}`},
+	}
+	suite := &gqltesting.Suite{
+		Before: func(t *testing.T) {
+			record("before")
+			schema := graphql.MustParseSchema(`type Query { hello: String! }`, &suiteResolver{record: record})
+			for _, test := range tests {
+				test.Schema = schema
+			}
+		},
+		After: func(t *testing.T) {
+			record("after")
+		},
+		Tests: tests,
+	}
+	suite.Run(t, gqltesting.WithParallel())
+	if got, want := strings.Join(events, ","), "before,hello,hello,after"; got != want {
+		t.Errorf("got events %s, want %s", got, want)
+	}
+
+	// A single parallel test still completes before After.
+	events = nil
+	suite.Tests = tests[:1]
+	suite.Run(t, gqltesting.WithParallel())
+	if got, want := strings.Join(events, ","), "before,hello,after"; got != want {
+		t.Errorf("got events %s with a single parallel test, want %s", got, want)
+	}
+
+	events = nil
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic of Before to propagate")
+			}
+		}()
+		(&gqltesting.Suite{
+			Before: func(t *testing.T) { panic("setup failed") },
+			After:  func(t *testing.T) { record("after") },
+		}).Run(t)
+	}()
+	if got, want := strings.Join(events, ","), "after"; got != want {
+		t.Errorf("got events %s, want %s after a panic", got, want)
+	}
+}
+
+func TestRunTest_exactShape(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         helloSchema,
+		Query:          `{ users { id name } }`,
+		ExpectedResult: `{"users": [{"id": "<ANY>", "name": "Alice"}, {"id": "<ANY>", "name": "Bob"}]}`,
+	}, gqltesting.WithExactShape())
+}
+
+type readOnlyKey struct{}
+
+type readOnlyResolver struct{}
+
+func (r *readOnlyResolver) Version() string {
+	return "1"
+}
+
+func (r *readOnlyResolver) DeleteUser(ctx context.Context, args struct{ ID graphql.ID }) (*bool, error) {
+	if readOnly, _ := ctx.Value(readOnlyKey{}).(bool); readOnly {
+		return nil, errors.New("mutations are not allowed in a read-only context")
+	}
+	deleted := true
+	return &deleted, nil
+}
+
+func TestRunRejectionTest(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+			mutation: Mutation
+		}
+
+		type Query {
+			version: String!
+		}
+
+		type Mutation {
+			deleteUser(id: ID!): Boolean
+		}
+	`, &readOnlyResolver{})
+	test := &gqltesting.Test{
+		Schema: schema,
+		Query:  `mutation { a: deleteUser(id: "1") b: deleteUser(id: "2") }`,
+	}
+
+	gqltesting.RunRejectionTest(t, test, "mutation", "read-only", gqltesting.WithContextValue(readOnlyKey{}, true))
+
+	allowed := *test
+	allowed.ExpectedResult = `{"a": true, "b": true}`
+	gqltesting.RunTest(t, &allowed)
+}
+
+type searchResolver struct{}
+
+type searchResultResolver struct {
+	name  string
+	droid bool
+}
+
+func (r *searchResolver) Search() []*searchResultResolver {
+	return []*searchResultResolver{{name: "R2-D2", droid: true}, {name: "C-3PO", droid: true}}
+}
+
+func (r *searchResultResolver) ToHuman() (*searchResultResolver, bool) {
+	return r, !r.droid
+}
+
+func (r *searchResultResolver) ToDroid() (*searchResultResolver, bool) {
+	return r, r.droid
+}
+
+func (r *searchResultResolver) Name() string {
+	return r.name
+}
+
+func TestAssertTypename(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			search: [SearchResult!]!
+		}
+
+		union SearchResult = Human | Droid
+
+		type Human {
+			name: String!
+		}
+
+		type Droid {
+			name: String!
+		}
+	`, &searchResolver{})
+
+	gqltesting.AssertTypename(t, schema, `{ search { __typename } }`, nil, "search[]", "Droid")
+	gqltesting.AssertTypename(t, schema, `{ search { ... on Droid { name } __typename } }`, nil, "search[1]", "Droid")
+}
+
+func TestRunTest_fixtureFile(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:      helloSchema,
+		Query:       `{ hello fail }`,
+		FixtureFile: "testdata/fixture.json",
+	})
+}
+
+type bubblingResolver struct{}
+
+func (r *bubblingResolver) User() *bubblingResolver {
+	return r
+}
+
+func (r *bubblingResolver) Profile() *bubblingResolver {
+	return r
+}
+
+func (r *bubblingResolver) Name() (string, error) {
+	return "", errors.New("profile unavailable")
+}
+
+func (r *bubblingResolver) Nickname() (*string, error) {
+	return nil, errors.New("nickname unavailable")
+}
+
+func TestAssertNullBubbling(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			user: User
+		}
+
+		type User {
+			profile: Profile!
+		}
+
+		type Profile {
+			name: String!
+			nickname: String
+		}
+	`, &bubblingResolver{})
+
+	result := schema.Exec(context.Background(), `{ user { profile { name } } }`, "", nil)
+	gqltesting.AssertNullBubbling(t, result, []interface{}{"user", "profile", "name"}, []interface{}{"user"})
+
+	result = schema.Exec(context.Background(), `{ user { profile { nickname } } }`, "", nil)
+	gqltesting.AssertNullBubbling(t, result, []interface{}{"user", "profile", "nickname"}, []interface{}{"user", "profile", "nickname"})
+}
+
+func TestAssertNoDeprecatedFields(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String! @deprecated(reason: "Use greet instead.")
+			greet: String!
+		}
+	`, nil)
+	gqltesting.AssertNoDeprecatedFields(t, schema, `query Greet { greet __typename }`)
+}
+
+func TestAssertErrorAtPath(t *testing.T) {
+	result := helloSchema.Exec(context.Background(), `{ hello fail }`, "", nil)
+	gqltesting.AssertErrorAtPath(t, result, []interface{}{"fail"})
+	gqltesting.AssertErrorAtPath(t, result, "data.fail")
+}
+
+type tickResolver struct{}
+
+type tickEventResolver struct {
+	n int32
+}
+
+func (r *tickResolver) Hello() string {
+	return "Hello world!"
+}
+
+func (r *tickResolver) Ticks(ctx context.Context) <-chan *tickEventResolver {
+	c := make(chan *tickEventResolver)
+	go func() {
+		defer close(c)
+		for n := int32(1); ; n++ {
+			select {
+			case c <- &tickEventResolver{n: n}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func (r *tickEventResolver) N() int32 {
+	return r.n
+}
+
+func TestRunSubscriptionCancellationTest(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+			subscription: Subscription
+		}
+
+		type Query {
+			hello: String!
+		}
+
+		type Subscription {
+			ticks: Tick!
+		}
+
+		type Tick {
+			n: Int!
+		}
+	`, &tickResolver{})
+
+	gqltesting.RunSubscriptionCancellationTest(t, &gqltesting.SubscriptionTest{
+		Schema: schema,
+		Query:  `subscription { ticks { n } }`,
+		ExpectedResults: []gqltesting.TestResponse{
+			{Data: json.RawMessage(`{"ticks": {"n": 1}}`)},
+			{Data: json.RawMessage(`{"ticks": {"n": 2}}`)},
+		},
+	}, time.Second)
+}
+
+func TestTestBuilder(t *testing.T) {
+	greet := gqltesting.NewTest(helloSchema).
+		Query(`query Greet($name: String!) { greet(name: $name) }`).
+		OperationName("Greet")
+	you := greet.Name("you").Var("name", "you").Expect(`{"greet": "Hello you!"}`).Build()
+	me := greet.Name("me").Var("name", "me").Expect(`{"greet": "Hello me!"}`).Build()
+	if you.Variables["name"] != "you" {
+		t.Errorf("building a variant changed variable name of an earlier test to %v", you.Variables["name"])
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		you,
+		me,
+		gqltesting.NewTest(helloSchema).
+			Name("unknown field").
+			Query(`{ goodbye }`).
+			ExpectError(&gqlerrors.QueryError{
+				Message:   `Cannot query field "goodbye" on type "Query".`,
+				Locations: []gqlerrors.Location{{Line: 1, Column: 3}},
+				Rule:      "FieldsOnCorrectType",
+			}).
+			Build(),
+		gqltesting.NewTest(helloSchema).
+			Name("ignored field").
+			Query(`{ hello requestId }`).
+			Expect(`{"hello": "Hello world!"}`).
+			IgnoreFields("data.requestId").
+			ExpectNoErrors().
+			Build(),
+	})
+}
+
+type roleKey struct{}
+
+type staffResolver struct{}
+
+func (*staffResolver) Me() *employeeResolver {
+	return &employeeResolver{}
+}
+
+type employeeResolver struct{}
+
+func (*employeeResolver) Name() string {
+	return "Alice"
+}
+
+func (*employeeResolver) Email(ctx context.Context) (*string, error) {
+	if role := ctx.Value(roleKey{}); role != "staff" && role != "admin" {
+		return nil, errors.New("forbidden: requires role staff")
+	}
+	email := "alice@example.com"
+	return &email, nil
+}
+
+func (*employeeResolver) Salary(ctx context.Context) (int32, error) {
+	if ctx.Value(roleKey{}) != "admin" {
+		return 0, errors.New("forbidden: requires role admin")
+	}
+	return 100, nil
+}
+
+func TestRunAuthorizationTest(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			me: Employee
+		}
+
+		type Employee {
+			name: String!
+			email: String
+			salary: Int!
+		}
+	`, &staffResolver{})
+
+	permissions := []struct {
+		role    string
+		query   string
+		denied  []string
+		allowed []string
+	}{
+		{role: "guest", query: `{ me { name email } }`, denied: []string{"me.email"}, allowed: []string{"me.name"}},
+		{role: "staff", query: `{ me { name email } }`, allowed: []string{"me.name", "me.email"}},
+		// Salary is non-null, so denying it nulls the whole employee.
+		{role: "staff", query: `{ me { name salary } }`, denied: []string{"me.salary"}},
+		{role: "admin", query: `{ me { name email salary } }`, allowed: []string{"me.name", "me.email", "me.salary"}},
+	}
+	for _, p := range permissions {
+		p := p
+		t.Run(p.role+" "+p.query, func(t *testing.T) {
+			gqltesting.RunAuthorizationTest(t, &gqltesting.Test{Schema: schema, Query: p.query}, "forbidden",
+				p.denied, p.allowed, gqltesting.WithContextValue(roleKey{}, p.role))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package gqltesting
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// DefaultTimeout bounds the execution of every test run by RunTest whose context has no deadline
+// and which is not given WithTimeout. A query that runs into the deadline fails the test with a
+// message naming the query, instead of hanging until go test panics. Set it to zero to disable
+// the default.
+var DefaultTimeout = 30 * time.Second
+
+// blockingResolverGrace is how long execWithDeadline waits for Exec to return once the deadline
+// has passed, so that resolvers honoring the context can still produce a result.
+const blockingResolverGrace = time.Second
+
+// timeoutContext wraps ctx in a context.WithTimeout of d, if d is positive.
+func timeoutContext(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return ctx, func() {}
+}
+
+// execTest executes test once with the context and timeout configured by cfg.
+func execTest(t testing.TB, test *Test, cfg *runConfig, variables map[string]interface{}) *graphql.Response {
+	parent := testContext(test, cfg)
+	timeout := testTimeout(parent, cfg)
+	ctx, cancel := timeoutContext(parent, timeout)
+	defer cancel()
+	return execWithDeadline(t, ctx, parent, timeout, cfg.execFunc(), test, variables, 1)[0]
+}
+
+// testTimeout returns the timeout to execute test with: the one given by WithTimeout, or else
+// DefaultTimeout if the test context has no deadline of its own.
+func testTimeout(ctx context.Context, cfg *runConfig) time.Duration {
+	if cfg.timeout > 0 {
+		return cfg.timeout
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return 0
+	}
+	return DefaultTimeout
+}
+
+// execWithDeadline executes test n times in parallel (see WithConcurrency) using exec with ctx,
+// whose deadline was set by the harness from parent. If that deadline passes, the test fails with
+// a dedicated message rather than with a mismatch on the "context deadline exceeded" errors in the
+// result. A resolver that ignores the context would block Exec indefinitely, so it is abandoned,
+// still running, after blockingResolverGrace.
+func execWithDeadline(t testing.TB, ctx, parent context.Context, timeout time.Duration, exec execFunc, test *Test, variables map[string]interface{}, n int) []*graphql.Response {
+	type outcome struct {
+		results []*graphql.Response
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		results, err := execConcurrently(ctx, exec, test, variables, n)
+		done <- outcome{results, err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		select {
+		case out = <-done:
+		case <-time.After(blockingResolverGrace):
+		}
+	}
+	results := out.results
+
+	if timeout > 0 && ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+		t.Fatalf("query exceeded %s; likely a blocking resolver\nquery: %s", timeout, test.Query)
+	}
+	if out.err != nil {
+		t.Fatal(out.err)
+	}
+	if results == nil {
+		t.Fatalf("query still running after its context was done; likely a blocking resolver\nquery: %s", test.Query)
+	}
+	return results
+}
+
+// RunFieldTimeoutTest asserts that a resolver enforces a per-field timeout derived from the
+// request context, cancelling cleanly. It executes test with a request context whose deadline
+// passes after timeout, a known bound from which resolvers derive shorter deadlines of their own,
+// for example with context.WithTimeout. The deliberately slow resolver at path must give up once
+// its own deadline passes, with an error caused by context.DeadlineExceeded or context.Canceled,
+// and must be the only one to fail. If test.ExpectedResult is set, it is compared against the
+// data, so that the sibling fields are checked to still resolve. Since the executor discards the
+// whole result once the request context is done, the test fails if the request deadline passes
+// first. The other expectations of test are ignored.
+func RunFieldTimeoutTest(t *testing.T, test *Test, timeout time.Duration, path []interface{}, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	if cfg.parallel {
+		t.Parallel()
+	}
+
+	ctx, cancel := context.WithTimeout(testContext(test, cfg), timeout)
+	defer cancel()
+	result := execWithDeadline(t, ctx, ctx, 0, cfg.execFunc(), test, testVariables(t, test), 1)[0]
+	if ctx.Err() != nil {
+		t.Fatalf("the request deadline of %s passed before the field at %v gave up; it does not enforce a shorter timeout of its own", timeout, path)
+	}
+
+	var found bool
+	for _, err := range result.Errors {
+		if _, ok := firstPathDifference(path, err.Path); !ok {
+			t.Errorf("got error %q at path %v, want only the slow field at %v to fail", err.Message, err.Path, path)
+			continue
+		}
+		if !isCancellation(err) {
+			t.Errorf("got error %q at path %v, want a context cancellation error", err.Message, path)
+		}
+		found = true
+	}
+	if !found {
+		t.Errorf("got no error at path %v; the resolver did not report the cancellation of its context", path)
+	}
+
+	if test.ExpectedResult != "" {
+		checkData(t, testComparison(test, cfg), []byte(test.ExpectedResult), result.Data)
+	}
+}
+
+// isCancellation reports whether err was caused by the cancellation of the request context. It
+// inspects the message, which mentions the context error even when the resolver wrapped it, and
+// is all that is left of the error after an HTTP round trip.
+func isCancellation(err *errors.QueryError) bool {
+	return strings.Contains(err.Message, context.DeadlineExceeded.Error()) || strings.Contains(err.Message, context.Canceled.Error())
+}
@@ -0,0 +1,48 @@
+package gqltesting
+
+import (
+	"context"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// AssertTypename executes query against schema and asserts that the objects at the dot-path p,
+// written as for AssertFieldPresent, such as "node" or "search[]", are of the concrete type
+// wantType according to their __typename, which the query must select. It targets the resolution
+// of interfaces and unions, where a resolver returning the wrong concrete type is otherwise only
+// visible in a comparison of the whole result. The query must succeed, and wantType must be an
+// object type of schema.
+func AssertTypename(t *testing.T, schema *graphql.Schema, query string, variables map[string]interface{}, p, wantType string) {
+	t.Helper()
+	if typ := lookupType(schema, wantType); typ == nil || typ.Kind() != "OBJECT" {
+		t.Fatalf("schema has no object type %q", wantType)
+	}
+	result := schema.Exec(context.Background(), query, "", variables)
+	if len(result.Errors) > 0 {
+		t.Fatalf("unexpected errors: %s", errorMessages(result.Errors))
+	}
+
+	typenamePath := "__typename"
+	if p != "" {
+		typenamePath = p + "." + typenamePath
+	}
+	objects, _ := fieldParents(t, result, typenamePath)
+	if len(objects) == 0 {
+		t.Fatalf("%s: no such field in result", p)
+	}
+	for _, v := range objects {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: got %v, want an object of type %s", p, v, wantType)
+			continue
+		}
+		typename, ok := obj["__typename"].(string)
+		if !ok {
+			t.Fatalf("%s: the query does not select __typename", p)
+		}
+		if typename != wantType {
+			t.Errorf("%s: got __typename %s, want %s", p, typename, wantType)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gqltesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// RunVariableCoercionTest executes query with the variables given as raw JSON, as a client would
+// send them, and checks that the operation fails with exactly the error want. If want is nil, the
+// variables must be accepted without errors. The cases are reported distinctly, with the type
+// the variable is declared with:
+//
+//   - a required variable that is not provided: `Variable "id" of required type "ID!" was not
+//     provided.`, with the rule VariablesOfCorrectType
+//   - null for a non-null variable: `Variable "id" has invalid value null.`, followed by the
+//     expected type, with the rule VariablesOfCorrectType
+//   - a value of the wrong type, such as a string for an Int: `Variable "n" has invalid value
+//     ...`, followed by the expected type, with the rule VariablesOfCorrectType
+//   - a variable no operation of the query declares: `Variable "x" is not defined by any
+//     operation.`, without location, with the rule NoUnknownVariables
+func RunVariableCoercionTest(t *testing.T, schema *graphql.Schema, query, variablesJSON string, want *errors.QueryError, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	test := &Test{Schema: schema, Query: query, VariablesJSON: variablesJSON}
+	result := execTest(t, test, cfg, testVariables(t, test))
+	if want == nil {
+		checkNoErrors(t, result.Errors)
+		return
+	}
+	checkErrors(t, []*errors.QueryError{want}, result.Errors, cfg.strictErrors, false)
+}
+
+// testVariables returns the variables of test, decoding them from VariablesJSON or VariablesFile
+// if given. At most one of Variables, VariablesJSON and VariablesFile may be set.
+func testVariables(t testing.TB, test *Test) map[string]interface{} {
+	set := 0
+	for _, ok := range []bool{test.Variables != nil, test.VariablesJSON != "", test.VariablesFile != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		t.Fatal("only one of Variables, VariablesJSON and VariablesFile may be set")
+	}
+
+	data := []byte(test.VariablesJSON)
+	if test.VariablesFile != "" {
+		var err error
+		data, err = ioutil.ReadFile(test.VariablesFile)
+		if err != nil {
+			t.Fatalf("reading variables file: %s", err)
+		}
+	}
+	if len(data) == 0 {
+		return marshalStructVariables(t, test.Variables)
+	}
+
+	var variables map[string]interface{}
+	if err := json.Unmarshal(data, &variables); err != nil {
+		t.Fatalf("variables: invalid JSON: %s", err)
+	}
+	return variables
+}
+
+// marshalStructVariables returns variables with the values holding Go structs round-tripped
+// through JSON into generic values, which is what the executor coerces input from.
+func marshalStructVariables(t testing.TB, variables map[string]interface{}) map[string]interface{} {
+	var converted map[string]interface{}
+	for name, v := range variables {
+		if !holdsStruct(reflect.TypeOf(v)) {
+			continue
+		}
+		if converted == nil {
+			converted = make(map[string]interface{}, len(variables))
+			for k, v := range variables {
+				converted[k] = v
+			}
+		}
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("variable %q: %s", name, err)
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			t.Fatalf("variable %q: %s", name, err)
+		}
+		converted[name] = generic
+	}
+	if converted == nil {
+		return variables
+	}
+	return converted
+}
+
+// holdsStruct reports whether values of type typ are structs, pointers to structs or lists of them.
+func holdsStruct(typ reflect.Type) bool {
+	if typ == nil {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return holdsStruct(typ.Elem())
+	default:
+		return false
+	}
+}
+
+// RunEnumCoercionTest executes query, with the variables given as raw JSON if not empty, and checks
+// that it is rejected with a single error naming the enum type enumType and the invalid value, as
+// written in the query or variables. This covers both invalid enum literals, such as
+// hero(episode: empire) for an enum value EMPIRE, since enum values are case sensitive, and
+// invalid enum values of variables, reported by the ArgumentsOfCorrectType and
+// VariablesOfCorrectType rules respectively.
+func RunEnumCoercionTest(t *testing.T, schema *graphql.Schema, query, variablesJSON, enumType, value string, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	test := &Test{Schema: schema, Query: query, VariablesJSON: variablesJSON}
+	result := execTest(t, test, cfg, testVariables(t, test))
+	if len(result.Errors) != 1 {
+		t.Fatalf("got errors %s, want a single error for the invalid %s value %s", errorMessages(result.Errors), enumType, value)
+	}
+
+	msg := result.Errors[0].Message
+	for _, typ := range []string{enumType, enumType + "!"} {
+		if strings.HasSuffix(msg, fmt.Sprintf("Expected type %q, found %s.", typ, value)) {
+			return
+		}
+	}
+	t.Errorf("got error %q, want an error for the invalid %s value %s", msg, enumType, value)
+}
+
+// RunScalarParseErrorTest executes query, with the variables given as raw JSON if not empty, and
+// checks that it fails with a single error whose message contains wantMsg, the error returned by
+// the UnmarshalGraphQL method of a custom scalar rejecting its input, such as an invalid literal
+// for a DateTime argument. The same method parses both literals and variable values, but it is
+// given their Go values as decoded from the query or from JSON respectively, so the literal 3
+// arrives as an int32 while the variable value 3 arrives as a float64, and the messages of the two
+// cases may differ. If locations is not empty, the error must also report exactly these
+// locations; note that the executor currently reports scalar parse errors without locations or
+// path, whether the input came from a literal or a variable. The options configure the execution
+// as for RunTest.
+func RunScalarParseErrorTest(t *testing.T, schema *graphql.Schema, query, variablesJSON, wantMsg string, locations []errors.Location, opts ...Option) {
+	t.Helper()
+	cfg := newRunConfig(opts)
+	test := &Test{Schema: schema, Query: query, VariablesJSON: variablesJSON}
+	result := execTest(t, test, cfg, testVariables(t, test))
+	if len(result.Errors) != 1 {
+		t.Fatalf("got errors %s, want a single scalar parse error containing %q", errorMessages(result.Errors), wantMsg)
+	}
+
+	err := result.Errors[0]
+	if !strings.Contains(err.Message, wantMsg) {
+		t.Errorf("got error %q, want a scalar parse error containing %q", err.Message, wantMsg)
+	}
+	if len(locations) > 0 && !reflect.DeepEqual(err.Locations, locations) {
+		t.Errorf("got error locations %v, want %v", err.Locations, locations)
+	}
+}
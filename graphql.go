@@ -13,6 +13,7 @@ import (
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
 	"github.com/graph-gophers/graphql-go/internal/schema"
+	"github.com/graph-gophers/graphql-go/internal/testhook"
 	"github.com/graph-gophers/graphql-go/internal/validation"
 	"github.com/graph-gophers/graphql-go/introspection"
 	"github.com/graph-gophers/graphql-go/log"
@@ -146,12 +147,23 @@ type Response struct {
 
 // Validate validates the given query with the schema.
 func (s *Schema) Validate(queryString string) []*errors.QueryError {
+	return s.ValidateWithVariables(queryString, nil)
+}
+
+// ValidateWithVariables validates the given query with the schema and the input variables.
+func (s *Schema) ValidateWithVariables(queryString string, variables map[string]interface{}) []*errors.QueryError {
 	doc, qErr := query.Parse(queryString)
 	if qErr != nil {
 		return []*errors.QueryError{qErr}
 	}
 
-	return validation.Validate(s.schema, doc, nil, s.maxDepth)
+	return validation.Validate(s.schema, doc, variables, s.maxDepth)
+}
+
+func init() {
+	testhook.ValidateDocument = func(s interface{}, doc *query.Document, variables map[string]interface{}) []*errors.QueryError {
+		return validation.Validate(s.(*Schema).schema, doc, variables, s.(*Schema).maxDepth)
+	}
 }
 
 // Exec executes the given query with the schema's resolver. It panics if the schema was created
@@ -169,7 +181,37 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 	if qErr != nil {
 		return &Response{Errors: []*errors.QueryError{qErr}}
 	}
+	return s.execDocument(ctx, queryString, doc, operationName, variables, res)
+}
+
+// PreparedQuery is a query parsed once by Schema.Prepare, to be executed any number of times,
+// possibly concurrently.
+type PreparedQuery struct {
+	schema      *Schema
+	queryString string
+	doc         *query.Document
+}
+
+// Prepare parses the given query for repeated execution with PreparedQuery.Exec, which saves
+// parsing it again each time, such as in benchmarks. The query is still validated on every
+// execution, since the outcome depends on the variables.
+func (s *Schema) Prepare(queryString string) (*PreparedQuery, *errors.QueryError) {
+	doc, qErr := query.Parse(queryString)
+	if qErr != nil {
+		return nil, qErr
+	}
+	return &PreparedQuery{schema: s, queryString: queryString, doc: doc}, nil
+}
+
+// Exec executes the prepared query like Schema.Exec.
+func (q *PreparedQuery) Exec(ctx context.Context, operationName string, variables map[string]interface{}) *Response {
+	if q.schema.res.Resolver == (reflect.Value{}) {
+		panic("schema created without resolver, can not exec")
+	}
+	return q.schema.execDocument(ctx, q.queryString, q.doc, operationName, variables, q.schema.res)
+}
 
+func (s *Schema) execDocument(ctx context.Context, queryString string, doc *query.Document, operationName string, variables map[string]interface{}, res *resolvable.Schema) *Response {
 	validationFinish := s.validationTracer.TraceValidation()
 	errs := validation.Validate(s.schema, doc, variables, s.maxDepth)
 	validationFinish(errs)
@@ -1583,6 +1583,45 @@ func TestEnums(t *testing.T) {
 				},
 			},
 		},
+		// Required variable not supplied
+		{
+			Schema: starwarsSchema,
+			Query: `
+				query HeroForEpisode($episode: Episode!) {
+					hero(episode: $episode) {
+						name
+					}
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:   "Variable \"episode\" of required type \"Episode!\" was not provided.",
+					Locations: []gqlerrors.Location{{Column: 26, Line: 2}},
+					Rule:      "VariablesOfCorrectType",
+				},
+			},
+		},
+		// Variable of the wrong scalar type
+		{
+			Schema: starwarsSchema,
+			Query: `
+				query HeroFriends($first: Int) {
+					hero {
+						friendsConnection(first: $first) {
+							totalCount
+						}
+					}
+				}
+			`,
+			Variables: map[string]interface{}{"first": "two"},
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:   "Variable \"first\" has invalid value two.\nExpected type \"Int\", found two.",
+					Locations: []gqlerrors.Location{{Column: 23, Line: 2}},
+					Rule:      "VariablesOfCorrectType",
+				},
+			},
+		},
 		// Valid enum value in response
 		{
 			Schema: starwarsSchema,
@@ -3635,3 +3674,38 @@ func TestSubscriptions_In_Exec(t *testing.T) {
 		},
 	})
 }
+
+func TestPreparedQuery(t *testing.T) {
+	q, err := starwarsSchema.Prepare(`
+		query HeroName($episode: Episode) {
+			hero(episode: $episode) {
+				name
+			}
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for episode, want := range map[string]string{
+		"EMPIRE": `{"hero":{"name":"Luke Skywalker"}}`,
+		"JEDI":   `{"hero":{"name":"R2-D2"}}`,
+	} {
+		result := q.Exec(context.Background(), "HeroName", map[string]interface{}{"episode": episode})
+		if len(result.Errors) != 0 {
+			t.Fatalf("%s: unexpected errors: %v", episode, result.Errors)
+		}
+		if got := string(result.Data); got != want {
+			t.Errorf("%s: got %s, want %s", episode, got, want)
+		}
+	}
+
+	result := q.Exec(context.Background(), "HeroName", map[string]interface{}{"episode": "WRATH_OF_KHAN"})
+	if len(result.Errors) != 1 || result.Errors[0].Rule != "VariablesOfCorrectType" {
+		t.Errorf("got errors %v, want the invalid variable to be rejected on execution", result.Errors)
+	}
+
+	if _, err := starwarsSchema.Prepare(`{ hero {`); err == nil {
+		t.Error("prepared a query with a syntax error")
+	}
+}
@@ -0,0 +1,13 @@
+// Package testhook gives the gqltesting package access to the parts of the graphql package it
+// needs but that are not part of its public API.
+package testhook
+
+import (
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/query"
+)
+
+// ValidateDocument validates an already parsed document against schema, a *graphql.Schema, with
+// the given variables, as Schema.ValidateWithVariables does for a query string. It is set by the
+// graphql package.
+var ValidateDocument func(schema interface{}, doc *query.Document, variables map[string]interface{}) []*errors.QueryError
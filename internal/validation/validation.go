@@ -1,9 +1,11 @@
 package validation
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"text/scanner"
@@ -95,7 +97,13 @@ func Validate(s *schema.Schema, doc *query.Document, variables map[string]interf
 			if !canBeInput(t) {
 				c.addErr(v.TypeLoc, "VariablesAreInputTypes", "Variable %q cannot be non-input type %q.", "$"+v.Name.Name, t)
 			}
-			validateValue(opc, v, variables[v.Name.Name], t)
+			if _, ok := variables[v.Name.Name]; !ok && v.Default == nil {
+				if _, ok := t.(*common.NonNull); ok {
+					c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" of required type \"%s\" was not provided.", v.Name.Name, t)
+				}
+			} else {
+				validateValue(opc, v, variables[v.Name.Name], t, t)
+			}
 
 			if v.Default != nil {
 				validateLiteral(opc, v.Default)
@@ -161,6 +169,8 @@ func Validate(s *schema.Schema, doc *query.Document, variables map[string]interf
 		}
 	}
 
+	validateKnownVariables(c, doc, variables)
+
 	for _, op := range doc.Operations {
 		c.errs = append(c.errs, c.opErrs[op]...)
 
@@ -179,14 +189,39 @@ func Validate(s *schema.Schema, doc *query.Document, variables map[string]interf
 	return c.errs
 }
 
-func validateValue(c *opContext, v *common.InputValue, val interface{}, t common.Type) {
+// validateKnownVariables reports the variables given a value that no operation of doc declares.
+func validateKnownVariables(c *context, doc *query.Document, variables map[string]interface{}) {
+	declared := make(map[string]bool)
+	for _, op := range doc.Operations {
+		for _, v := range op.Vars {
+			declared[v.Name.Name] = true
+		}
+	}
+	var unknown []string
+	for name := range variables {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		c.errs = append(c.errs, &errors.QueryError{
+			Message: fmt.Sprintf("Variable \"%s\" is not defined by any operation.", name),
+			Rule:    "NoUnknownVariables",
+		})
+	}
+}
+
+// validateValue checks the value val given for v against t, the type of v or, within a list or
+// non-null type, of its elements. declared is the type of v itself, which errors report.
+func validateValue(c *opContext, v *common.InputValue, val interface{}, t, declared common.Type) {
 	switch t := t.(type) {
 	case *common.NonNull:
 		if val == nil {
 			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value null.\nExpected type \"%s\", found null.", v.Name.Name, t)
 			return
 		}
-		validateValue(c, v, val, t.OfType)
+		validateValue(c, v, val, t.OfType, declared)
 	case *common.List:
 		if val == nil {
 			return
@@ -194,11 +229,18 @@ func validateValue(c *opContext, v *common.InputValue, val interface{}, t common
 		vv, ok := val.([]interface{})
 		if !ok {
 			// Input coercion rules allow single items without wrapping array
-			validateValue(c, v, val, t.OfType)
+			validateValue(c, v, val, t.OfType, declared)
 			return
 		}
 		for _, elem := range vv {
-			validateValue(c, v, elem, t.OfType)
+			validateValue(c, v, elem, t.OfType, declared)
+		}
+	case *schema.Scalar:
+		if val == nil {
+			return
+		}
+		if !validateScalarValue(val, t) {
+			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value %v.\nExpected type \"%s\", found %v.", v.Name.Name, val, declared, val)
 		}
 	case *schema.Enum:
 		if val == nil {
@@ -226,8 +268,48 @@ func validateValue(c *opContext, v *common.InputValue, val interface{}, t common
 		}
 		for _, f := range t.Values {
 			fieldVal := in[f.Name.Name]
-			validateValue(c, f, fieldVal, f.Type)
+			validateValue(c, f, fieldVal, f.Type, f.Type)
+		}
+	}
+}
+
+// validateScalarValue reports whether the variable value val can be coerced to the built-in scalar
+// t. Only the values JSON decodes to, and the numbers and strings of the basic Go types, are
+// checked: other values, such as pointers or values of named types supplied by Go callers, are
+// left to the resolvers as before, as are the values of custom scalars.
+func validateScalarValue(val interface{}, t *schema.Scalar) bool {
+	switch t.Name {
+	case "Int", "Float", "String", "Boolean", "ID":
+	default:
+		return true
+	}
+
+	if n, ok := val.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
 		}
+		val = f
+	}
+	switch val.(type) {
+	case string:
+		return t.Name == "String" || t.Name == "ID"
+	case bool:
+		return t.Name == "Boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		f := reflect.ValueOf(val).Convert(reflect.TypeOf(float64(0))).Float()
+		switch t.Name {
+		case "Int":
+			return f == math.Trunc(f) && f >= math.MinInt32 && f <= math.MaxInt32
+		case "Float":
+			return true
+		case "ID":
+			return f == math.Trunc(f)
+		default:
+			return false
+		}
+	default:
+		return true
 	}
 }
 
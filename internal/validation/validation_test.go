@@ -68,6 +68,38 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+type name string
+
+func TestValidate_goVariableValues(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			greet(name: String!, times: Int!): String!
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+	d, err := query.Parse(`query Greet($name: String!, $times: Int!) { greet(name: $name, times: $times) }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	you := "you"
+	for _, vars := range []map[string]interface{}{
+		{"name": "you", "times": int32(2)},
+		{"name": &you, "times": uint8(2)},
+		{"name": name("you"), "times": 2},
+	} {
+		if errs := validation.Validate(s, d, vars, 0); len(errs) > 0 {
+			t.Errorf("variables %v: unexpected errors %v", vars, errs)
+		}
+	}
+}
+
 func sortLocations(errs []*errors.QueryError) {
 	for _, err := range errs {
 		locs := err.Locations
@@ -416,6 +416,92 @@ func TestRootOperations_validSubscriptionSchema(t *testing.T) {
 	})
 }
 
+func TestRunSubscriptionTest(t *testing.T) {
+	gqltesting.RunSubscriptionTests(t, []*gqltesting.SubscriptionTest{
+		{
+			Name: "delivers payloads",
+			Schema: graphql.MustParseSchema(schema, &rootResolver{
+				helloSaidResolver: &helloSaidResolver{
+					upstream: closedUpstream(
+						&helloSaidEventResolver{msg: "Hello world!"},
+						&helloSaidEventResolver{msg: "Hello again!"},
+					),
+				},
+			}),
+			Query: `
+				subscription onHelloSaid {
+					helloSaid {
+						msg
+					}
+				}
+			`,
+			ExpectedResults: []gqltesting.TestResponse{
+				{Data: json.RawMessage(`{"helloSaid": {"msg": "Hello world!"}}`)},
+				{Data: json.RawMessage(`{"helloSaid": {"msg": "Hello again!"}}`)},
+			},
+		},
+		{
+			Name: "delivers a payload carrying only errors",
+			Schema: graphql.MustParseSchema(schema, &rootResolver{
+				helloSaidResolver: &helloSaidResolver{
+					upstream: closedUpstream(&helloSaidEventResolver{err: resolverErr}),
+				},
+			}),
+			Query: `
+				subscription onHelloSaid {
+					helloSaid {
+						msg
+					}
+				}
+			`,
+			ExpectedResults: []gqltesting.TestResponse{
+				{Errors: []*qerrors.QueryError{{
+					Message:       resolverErr.Error(),
+					Path:          []interface{}{"helloSaid", "msg"},
+					ResolverError: resolverErr,
+				}}},
+			},
+		},
+		{
+			Name: "closes without payloads",
+			Schema: graphql.MustParseSchema(schema, &rootResolver{
+				helloSaidResolver: &helloSaidResolver{upstream: closedUpstream()},
+			}),
+			Query: `
+				subscription onHelloSaid {
+					helloSaid {
+						msg
+					}
+				}
+			`,
+		},
+		{
+			Name: "fails on first resolve",
+			Schema: graphql.MustParseSchema(schema, &rootResolver{
+				helloSaidResolver: &helloSaidResolver{err: resolverErr},
+			}),
+			Query: `
+				subscription onHelloSaid {
+					helloSaid {
+						msg
+					}
+				}
+			`,
+			ExpectedFinalError: qerrors.Errorf("%s", resolverErr),
+		},
+		{
+			Name: "fails to subscribe",
+			Schema: graphql.MustParseSchema(`
+				type Query {
+					hello: String!
+				}
+			`, &rootResolver{}),
+			Query:       `subscription { helloSaid { msg } }`,
+			ExpectedErr: errors.New("no subscriptions are offered by the schema"),
+		},
+	})
+}
+
 const schema = `
 	schema {
 		subscription: Subscription,